@@ -0,0 +1,268 @@
+package claimer
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Kqzz/MCsniperGO/log"
+	"github.com/Kqzz/MCsniperGO/pkg/mc"
+	"github.com/Kqzz/MCsniperGO/pkg/proxy"
+)
+
+const (
+	EventAuthProgress EventType = "auth_progress" // an account finished logging in or gave up, Count is how many are done
+
+	defaultAuthConcurrency = 4
+	defaultAuthPacing      = time.Second * 21
+	defaultAuthRetries     = 2
+	defaultAuthBackoff     = time.Second * 30
+)
+
+// AuthOptions tune how accounts log in before a snipe, the zero value uses the defaults
+type AuthOptions struct {
+	Concurrency int           // accounts logging in at once, defaults to 4
+	Pacing      time.Duration // gap between logins through the same proxy (or direct), defaults to 21s
+	Retries     int           // extra attempts after a transient error, defaults to 2, negative for none
+	Backoff     time.Duration // wait before the first retry, doubling after each, defaults to 30s
+}
+
+func (o AuthOptions) withDefaults() AuthOptions {
+	if o.Concurrency <= 0 {
+		o.Concurrency = defaultAuthConcurrency
+	}
+	if o.Pacing <= 0 {
+		o.Pacing = defaultAuthPacing
+	}
+	if o.Retries == 0 {
+		o.Retries = defaultAuthRetries
+	} else if o.Retries < 0 {
+		o.Retries = 0
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = defaultAuthBackoff
+	}
+	return o
+}
+
+// AuthResult is how logging in one account went
+type AuthResult struct {
+	Account  *mc.MCaccount
+	Err      error // nil if the account is usable
+	Attempts int   // 0 for accounts given as a bearer
+	Took     time.Duration
+}
+
+// AuthProgress is the state of the latest round of logins or gift code redemptions, kept in the stats
+type AuthProgress struct {
+	Total   int
+	Done    int
+	Failed  int
+	Results []AuthResult // in account order, filled in once every account is done
+}
+
+// permanent errors fail the account right away, retrying can't fix them
+func permanent(err error) bool {
+	return errors.Is(err, mc.ErrInvalidCredentials) ||
+		errors.Is(err, mc.ErrTwoFactor) ||
+		errors.Is(err, mc.ErrChildAccount) ||
+		errors.Is(err, mc.ErrNoXbox) ||
+		errors.Is(err, mc.ErrGiftCodeInvalid) ||
+		errors.Is(err, mc.ErrGiftCodeRejected) ||
+		errors.Is(err, mc.ErrHasProfile) ||
+		errors.Is(err, mc.ErrNoGiftCode)
+}
+
+type authenticator struct {
+	name     string // username being sniped, for events
+	opts     AuthOptions
+	prepare  func(account *mc.MCaccount) error  // one attempt at getting an account ready
+	progress func(st *StatsStore) *AuthProgress // where in the stats the round reports to
+
+	mu   sync.Mutex           // guards next
+	next map[string]time.Time // earliest the next login through each proxy may start
+}
+
+// authenticate logs every account in, up to Concurrency at once, pacing logins that share a proxy
+func authenticate(name string, accounts []*mc.MCaccount, opts AuthOptions) []AuthResult {
+	return runLogins(name, accounts, opts, prepareAccount, func(st *StatsStore) *AuthProgress { return &st.Auth })
+}
+
+// runLogins runs prepare for every account the way authenticate logs them in, reporting to the stats progress picks
+func runLogins(name string, accounts []*mc.MCaccount, opts AuthOptions, prepare func(account *mc.MCaccount) error, progress func(st *StatsStore) *AuthProgress) []AuthResult {
+	a := &authenticator{name: name, opts: opts.withDefaults(), prepare: prepare, progress: progress, next: map[string]time.Time{}}
+
+	updateStats(func(st *StatsStore) { *progress(st) = AuthProgress{Total: len(accounts)} })
+
+	if shared := len(boundProxies(accounts)); shared < len(accounts) && shared < a.opts.Concurrency {
+		log.Log("info", "%d accounts log in over %d connection(s), %v apart on each. bind accounts to proxies to log in faster", len(accounts), shared, a.opts.Pacing)
+	}
+
+	results := make([]AuthResult, len(accounts))
+	slots := make(chan struct{}, a.opts.Concurrency)
+	var wg sync.WaitGroup
+
+	// device code logins wait on someone entering a code, they go one at a time once the rest are done
+	devices := []int{}
+	for i, account := range accounts {
+		if deviceFlow(account) {
+			devices = append(devices, i)
+			continue
+		}
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, account *mc.MCaccount) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			results[i] = a.login(account)
+			a.finished(results[i])
+		}(i, account)
+	}
+	wg.Wait()
+
+	for _, i := range devices {
+		results[i] = a.login(accounts[i])
+		a.finished(results[i])
+	}
+
+	updateStats(func(st *StatsStore) { progress(st).Results = results })
+
+	logAuthSummary(results)
+	return results
+}
+
+// deviceFlow is true for accounts that log in with the device code flow instead of a password
+func deviceFlow(acc *mc.MCaccount) bool {
+	return acc.Bearer == "" && acc.Password == "code"
+}
+
+// wait holds a login until its proxy's pacing allows it, reserving the slot after it
+func (a *authenticator) wait(p *proxy.Proxy) {
+	a.mu.Lock()
+	at := time.Now()
+	if next := a.next[p.String()]; next.After(at) {
+		at = next
+	}
+	a.next[p.String()] = at.Add(a.opts.Pacing)
+	a.mu.Unlock()
+
+	time.Sleep(time.Until(at))
+}
+
+func (a *authenticator) login(account *mc.MCaccount) AuthResult {
+	useBoundProxy(account)
+
+	result := AuthResult{Account: account}
+	if account.Bearer != "" {
+		return result
+	}
+
+	start := time.Now()
+	backoff := a.opts.Backoff
+	for {
+		a.wait(account.Proxy)
+		result.Attempts++
+		result.Err = a.prepare(account)
+
+		// a retried device code login would ask for a new code, that's left to the user
+		if result.Err == nil || permanent(result.Err) || deviceFlow(account) || result.Attempts > a.opts.Retries {
+			break
+		}
+
+		log.Log("warn", "%v failed to log in via %v, retrying in %v: %v", accountName(account), account.Proxy.Redacted(), backoff, result.Err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	result.Took = time.Since(start)
+	return result
+}
+
+func (a *authenticator) finished(result AuthResult) {
+	var done, total int
+	updateStats(func(st *StatsStore) {
+		p := a.progress(st)
+		p.Done++
+		if result.Err != nil {
+			p.Failed++
+		}
+		done, total = p.Done, p.Total
+	})
+
+	acc := result.Account
+	event := Event{Type: EventAuthProgress, Name: a.name, AccType: acc.Type, Proxy: acc.Proxy.Redacted(), Account: RedactAccount(acc), Count: done}
+
+	switch {
+	case result.Err != nil:
+		emit("err", event, "[%d/%d] failed to authenticate %v via %v: %v", done, total, accountName(acc), acc.Proxy.Redacted(), result.Err)
+	case result.Attempts == 0:
+		emit("success", event, "[%d/%d] using bearer of %v", done, total, accountName(acc))
+	default:
+		emit("success", event, "[%d/%d] authenticated %v via %v", done, total, accountName(acc), acc.Proxy.Redacted())
+	}
+}
+
+// prepareAccount logs account in and checks it can claim a name, licensing gamepass accounts on the way
+func prepareAccount(account *mc.MCaccount) error {
+	err := account.MicrosoftAuthenticate(account.Proxy)
+	if err != nil {
+		return err
+	}
+
+	time.Sleep(time.Millisecond * 500)
+
+	switch account.Type {
+	case mc.MsGp:
+		if err := account.License(); err != nil {
+			return fmt.Errorf("failed to license: %v", err)
+		}
+
+		// a profile means the name gets changed rather than created
+		if account.LoadAccountInfo() == nil && account.Username != "" {
+			log.Log("info", "%s has profile %s, will change its name", accountName(account), account.Username)
+		} else {
+			log.Log("info", "%s has no profile yet, will create one", accountName(account))
+		}
+
+	case mc.Ms:
+		if _, err := account.NameChangeInfo(); err != nil {
+			return fmt.Errorf("failed to confirm name change: %v", err)
+		}
+
+	case mc.MsPr:
+		status, err := account.CheckEntitlement()
+		if err != nil {
+			return fmt.Errorf("failed to confirm gift code claim: %v", err)
+		}
+		if status.HasProfile {
+			return fmt.Errorf("%w (%v), it can't be used as a GC account", mc.ErrHasProfile, status.Username)
+		}
+		if !status.OwnsMinecraft {
+			return mc.ErrNoGiftCode
+		}
+	}
+	return nil
+}
+
+// accountName is how an account shows up in logs, its label if it has one
+func accountName(acc *mc.MCaccount) string {
+	if acc.Label != "" {
+		return acc.Label
+	}
+	if acc.Email == "" {
+		return "bearer ..." + log.LastQuarter(acc.Bearer)
+	}
+	return acc.Email
+}
+
+func logAuthSummary(results []AuthResult) {
+	log.Log("info", "%-32v %-4v %-28v %-8v %-8v %v", "account", "type", "proxy", "tries", "time", "result")
+	for _, r := range results {
+		result := "ok"
+		if r.Err != nil {
+			result = r.Err.Error()
+		}
+		log.Log("info", "%-32v %-4v %-28v %-8d %-8v %v", accountName(r.Account), r.Account.Type, r.Account.Proxy.Redacted(), r.Attempts, r.Took.Round(time.Second), result)
+	}
+}
@@ -0,0 +1,107 @@
+package claimer
+
+import (
+	"fmt"
+
+	"github.com/Kqzz/MCsniperGO/log"
+	"github.com/Kqzz/MCsniperGO/pkg/mc"
+	"github.com/Kqzz/MCsniperGO/pkg/proxy"
+)
+
+// ProxyPolicy decides which proxy each request of an account goes out through
+type ProxyPolicy string
+
+const (
+	RotateProxies ProxyPolicy = "rotate" // sends rotate over every proxy, an account only uses its own proxy to log in and check
+	BindProxies   ProxyPolicy = "bind"   // every account sticks to one proxy for auth, licensing, checks and sends
+)
+
+func ParseProxyPolicy(raw string) (ProxyPolicy, error) {
+	switch ProxyPolicy(raw) {
+	case "", RotateProxies:
+		return RotateProxies, nil
+	case BindProxies:
+		return BindProxies, nil
+	}
+	return "", fmt.Errorf("unknown proxy policy %q, use rotate or bind", raw)
+}
+
+// bindProxies gives every account that isn't bound yet the least used of proxies, earlier (faster) ones on ties.
+// accounts stay direct if there are no proxies.
+func bindProxies(accounts []*mc.MCaccount, proxies []*proxy.Proxy) {
+	if len(proxies) == 0 {
+		for _, acc := range accounts {
+			if acc.Proxy == nil {
+				warn("no proxies to bind accounts to, unbound accounts go direct")
+				return
+			}
+		}
+		return
+	}
+
+	uses := map[string]int{}
+	for _, acc := range accounts {
+		if acc.Proxy != nil {
+			uses[acc.Proxy.String()]++
+		}
+	}
+
+	bound := 0
+	for _, acc := range accounts {
+		if acc.Proxy != nil {
+			continue
+		}
+
+		least := proxies[0]
+		for _, p := range proxies[1:] {
+			if uses[p.String()] < uses[least.String()] {
+				least = p
+			}
+		}
+
+		acc.Proxy = least
+		uses[least.String()]++
+		bound++
+	}
+
+	if bound > 0 {
+		log.Log("info", "bound %d account(s) to %d proxies", bound, len(proxies))
+	}
+	if len(accounts) > len(uses) {
+		warn("%d accounts share %d proxies, bound accounts on the same proxy share its ratelimit", len(accounts), len(uses))
+	}
+}
+
+// useBoundProxy sends the account's own requests (license, checks, skin) through its proxy, if it has one
+func useBoundProxy(acc *mc.MCaccount) {
+	if acc.Proxy == nil {
+		return
+	}
+	if acc.FastHttpClient == nil {
+		acc.DefaultFastHttpHandler()
+	}
+	acc.SetProxy(acc.Proxy)
+}
+
+// boundProxies is every distinct proxy accounts are bound to, nil standing in for the ones that go direct
+func boundProxies(accounts []*mc.MCaccount) []*proxy.Proxy {
+	proxies := []*proxy.Proxy{}
+	seen := map[string]bool{}
+	for _, acc := range accounts {
+		key := acc.Proxy.String()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		proxies = append(proxies, acc.Proxy)
+	}
+	return proxies
+}
+
+// proxyPolicy describes the policy in effect for the plan output
+func (s *Claim) proxyPolicy() string {
+	if s.Options.ProxyPolicy == BindProxies {
+		return "each account bound to its own proxy"
+	}
+	return "rotating every send"
+}
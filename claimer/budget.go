@@ -0,0 +1,228 @@
+package claimer
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Kqzz/MCsniperGO/log"
+	"github.com/Kqzz/MCsniperGO/pkg/mc"
+)
+
+const DefaultLedgerPath = "budget.json"
+
+// Ledger remembers every request sent per account so ratelimit budgets carry over between claims.
+type Ledger struct {
+	mu       sync.Mutex
+	path     string
+	Accounts map[string][]time.Time `json:"accounts"` // send times per account, oldest first
+}
+
+// accountKey identifies an account across runs, by uuid when known and email otherwise
+func accountKey(acc *mc.MCaccount) string {
+	if acc.UUID != "" {
+		return acc.UUID
+	}
+	if acc.Email != "" {
+		return acc.Email
+	}
+	return log.LastQuarter(acc.Bearer)
+}
+
+// ledgerFiles is held while a ledger file is read back and rewritten, so snipes running side by side don't drop each other's sends
+var ledgerFiles sync.Mutex
+
+// LoadLedger reads the ledger at path, starting an empty one if it doesn't exist yet.
+// on an error the returned ledger is empty too, nothing half read is kept.
+func LoadLedger(path string) (*Ledger, error) {
+	accounts, err := readLedger(path)
+	if err != nil {
+		accounts = map[string][]time.Time{}
+	}
+	return &Ledger{path: path, Accounts: accounts}, err
+}
+
+// readLedger reads the send times in the ledger file at path, none if it doesn't exist
+func readLedger(path string) (map[string][]time.Time, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string][]time.Time{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	file := struct {
+		Accounts map[string][]time.Time `json:"accounts"`
+	}{}
+	if err = json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if file.Accounts == nil {
+		file.Accounts = map[string][]time.Time{}
+	}
+	return file.Accounts, nil
+}
+
+func (l *Ledger) Record(acc *mc.MCaccount, at time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := accountKey(acc)
+	l.Accounts[key] = append(l.Accounts[key], at)
+}
+
+// Remaining returns how many more requests acc can send at the given time in its short and long windows
+func (l *Ledger) Remaining(acc *mc.MCaccount, at time.Time) (short int, long int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limit := limitsFor(acc.Type)
+	short, long = limit.perShort, limit.perLong
+
+	for _, sent := range l.Accounts[accountKey(acc)] {
+		if sent.After(at) {
+			continue
+		}
+		if at.Sub(sent) < limit.short {
+			short--
+		}
+		if at.Sub(sent) < limit.long {
+			long--
+		}
+	}
+
+	if short < 0 {
+		short = 0
+	}
+	if long < 0 {
+		long = 0
+	}
+	return short, long
+}
+
+// Available is true when acc has budget left in both windows at the given time
+func (l *Ledger) Available(acc *mc.MCaccount, at time.Time) bool {
+	short, long := l.Remaining(acc, at)
+	return short > 0 && long > 0
+}
+
+// WindowReset returns when the oldest send in acc's current short window falls out of it
+func (l *Ledger) WindowReset(acc *mc.MCaccount, at time.Time) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	window := limitsFor(acc.Type).short
+	for _, sent := range l.Accounts[accountKey(acc)] {
+		if !sent.After(at) && at.Sub(sent) < window {
+			return sent.Add(window)
+		}
+	}
+	return at.Add(window)
+}
+
+// Save merges in what other snipes wrote since the ledger was loaded, drops entries older than the
+// long window and replaces the file on disk with the result
+func (l *Ledger) Save() error {
+	ledgerFiles.Lock()
+	defer ledgerFiles.Unlock()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// a file that can't be read is replaced, like LoadLedger would have started over anyway
+	onDisk, err := readLedger(l.path)
+	if err != nil {
+		log.Log("warn", "request ledger %v is unreadable, overwriting it: %v", l.path, err)
+		onDisk = map[string][]time.Time{}
+	}
+	for key, sends := range onDisk {
+		l.Accounts[key] = mergeSends(l.Accounts[key], sends)
+	}
+
+	cutoff := time.Now().Add(-limitsFor(mc.Ms).long)
+	for key, sends := range l.Accounts {
+		kept := []time.Time{}
+		for _, sent := range sends {
+			if sent.After(cutoff) {
+				kept = append(kept, sent)
+			}
+		}
+		if len(kept) == 0 {
+			delete(l.Accounts, key)
+			continue
+		}
+		l.Accounts[key] = kept
+	}
+
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(l.path, data)
+}
+
+// mergeSends joins two lists of send times, oldest first and without repeats
+func mergeSends(a, b []time.Time) []time.Time {
+	merged := append(append([]time.Time{}, a...), b...)
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Before(merged[j]) })
+
+	unique := merged[:0]
+	for i, sent := range merged {
+		if i == 0 || !sent.Equal(merged[i-1]) {
+			unique = append(unique, sent)
+		}
+	}
+	return unique
+}
+
+// writeFileAtomic writes data next to path and renames it over path, so a crash never leaves half a file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// dropExhausted removes accounts that have nothing left in their long window at the start of the drop
+func dropExhausted(ledger *Ledger, accounts []*mc.MCaccount, start time.Time) []*mc.MCaccount {
+	usable := []*mc.MCaccount{}
+	for i, acc := range accounts {
+		if _, long := ledger.Remaining(acc, start); long == 0 {
+			warn("account #%d (%v) has used its %d requests / 24h, skipping it", i+1, acc.Type, limitsFor(acc.Type).perLong)
+			continue
+		}
+		usable = append(usable, acc)
+	}
+	return usable
+}
+
+// warnShortfall warns about accounts that can't send their share of a finite plan
+func warnShortfall(ledger *Ledger, accounts []*mc.MCaccount, plan Plan, start time.Time) {
+	if len(accounts) == 0 || plan.Repeat > 0 {
+		return
+	}
+
+	share := (len(plan.Offsets) + len(accounts) - 1) / len(accounts)
+	for i, acc := range accounts {
+		if _, long := ledger.Remaining(acc, start); long < share {
+			warn("account #%d (%v) can only send %d of its ~%d planned requests", i+1, acc.Type, long, share)
+		}
+	}
+}
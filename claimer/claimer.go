@@ -1,30 +1,47 @@
 package claimer
 
 import (
-	"math"
-	"strings"
+	"reflect"
+	"sort"
+	"sync"
 	"time"
 
+	"github.com/Kqzz/MCsniperGO/pkg/availability"
 	"github.com/Kqzz/MCsniperGO/pkg/mc"
-	"github.com/valyala/fasthttp"
-	"github.com/valyala/fasthttp/fasthttpproxy"
+	"github.com/Kqzz/MCsniperGO/pkg/proxy"
 
 	"github.com/Kqzz/MCsniperGO/log"
 )
 
-var workerCount = 100
+var accTypes = []mc.AccType{mc.Ms, mc.MsPr, mc.MsGp}
 
 type Claim struct {
 	Username  string
 	Running   bool
 	DropRange mc.DropRange
 	Accounts  []*mc.MCaccount
-	Proxies   []string
+	Proxies   []*proxy.Proxy
+	Options   Options
+
+	ledger     *Ledger
+	throttle   *throttle
+	quarantine *quarantine
+	workers    *workerPool
+	timings    *timings
+	winner     winner
+	verifying  sync.Mutex     // one 200 is checked at a time
+	verifies   sync.WaitGroup // checks of 200s still running
+	pending    pendingClaims  // 200s not confirmed yet
+	latency    time.Duration  // round trip seen while prewarming, 0 if it wasn't measured
+	rotation   []*proxy.Proxy // Proxies weighted by their checked latency, nil to rotate evenly
 }
 
 func (c *Claim) Start() {
 	c.Running = true
-	go c.runClaim()
+	go func() {
+		c.runClaim()
+		c.followUp()
+	}()
 }
 
 func (c *Claim) Stop() {
@@ -37,81 +54,182 @@ type ClaimAttempt struct {
 	Bearer  string
 	AccType mc.AccType
 	AccNum  int
-	Proxy   string
+	Proxy   *proxy.Proxy
+	Account *mc.MCaccount
+
+	Scheduled  time.Time // when the plan wanted it sent
+	Dispatched time.Time // when the generator handed it to the worker pool
 }
 
-func requestGenerator(
-	workChan chan ClaimAttempt,
-	killChan chan bool,
-	bearers []string,
-	name string,
+// requestGenerator walks the plan from start until the drop ends, handing each send to a worker.
+// every account gets a run of loopCount consecutive sends, proxies rotate on every send unless accounts are bound to theirs.
+// accounts without ratelimit budget or cooling down after a 429 are passed over, as are benched proxies.
+// when accounts start or finish cooling down the rest of the drop is planned again with scheduler for the ones that are ready.
+// sends within Options.Precise of start spin-wait their last moments instead of trusting a sleep.
+func (s *Claim) requestGenerator(
+	accounts []*mc.MCaccount,
 	accType mc.AccType,
-	endTime time.Time,
-	proxies []string,
-	delay int,
+	start time.Time,
+	scheduler Scheduler,
+	req PlanRequest,
+	plan Plan,
 ) {
-	noEnd := endTime.IsZero()
-	if len(bearers) == 0 {
+	if len(accounts) == 0 {
 		return
 	}
 
-	sleepTime := delay
-
-	if delay == -1 {
-		// prevents overrequesting in cases where there are large discrepencies in account/proxy counts
-		nMax := int(math.Min(float64(len(bearers)), float64(len(proxies))))
-		/*
-			ratelimits are set up as follows - 3 requests / 30s (2/30s for giftcard accounts), 40 requests / 24h.
-			we compute both. this is technically not maximally performant - you need around 9.25 octillion accounts
-			(which is a number 200 quadrillion times larger than every possible ipv4 address) for it to become relevant
-			assuming a constant request stream
-		*/
-		day := int((time.Hour * 24).Milliseconds())
-		// if under ratelimit periods for our drop range, we should use the drop range instead of the ratelimit period
-		shortInterval := int(math.Min(30000, float64(time.Until(endTime).Milliseconds())))
-		longInterval := int(math.Min(float64(day), float64(time.Until(endTime).Milliseconds())))
-		var deltaShort int
-		if accType == mc.Ms {
-			deltaShort = shortInterval / 3 / nMax
-		} else {
-			deltaShort = shortInterval / 2 / nMax
-		}
-		deltaLong := longInterval / 40 / nMax
-		// take the higher of the two
-		sleepTime = int(math.Max(float64(deltaShort), float64(deltaLong)))
-	}
-	loopCount := 2
-	if accType == mc.Ms {
-		loopCount = 3
+	proxies := s.Proxies
+	if len(s.rotation) > 0 && s.Options.ProxyPolicy != BindProxies {
+		proxies = s.rotation
 	}
+	endTime := s.DropRange.End
+
+	loopCount := limitsFor(accType).perShort
 	i := 0
+	y := 0
 	prox := 0
-	for noEnd || time.Now().Before(endTime) {
-		for y := 0; y < loopCount; y++ { // run n times / bearer
-			if i >= len(bearers) {
-				i = 0
+	base := start
+	starved := false
+	precise := start.Add(s.Options.Precise)
+	bound := s.Options.ProxyPolicy == BindProxies
+
+	// next account from i on with budget left at the given time, -1 if there is none
+	pick := func(at time.Time) int {
+		for n := 0; n < len(accounts); n++ {
+			idx := (i + n) % len(accounts)
+			acc := accounts[idx]
+			if bound && !s.throttle.proxyReady(acc.Proxy, at) {
+				continue
 			}
-
-			if prox >= len(proxies) {
-				prox = 0
+			if s.quarantine.inRotation(acc) && s.ledger.Available(acc, at) && s.throttle.accountReady(acc, at) {
+				return idx
 			}
+		}
+		return -1
+	}
+
+	// plans again for the accounts that aren't cooling down, true if that changed the plan
+	planned := req.Accounts
+	rebalance := func(at time.Time) bool {
+		ready := s.throttle.ready(accounts, at)
+		if ready == planned || ready == 0 {
+			return false
+		}
+		planned = ready
+
+		replan := req
+		replan.Accounts = ready
+		next := scheduler.Plan(replan)
+		if reflect.DeepEqual(next, plan) {
+			return false
+		}
+		plan = next
+
+		emit("info", Event{Type: EventRebalance, Name: s.Username, AccType: accType, Count: ready}, "planned the rest of the drop for %d/%d %v account(s)", ready, len(accounts), accType)
+		return true
+	}
 
-			workChan <- ClaimAttempt{
-				Name:    name,
-				Bearer:  bearers[i],
-				AccType: accType,
-				Proxy:   proxies[prox],
-				AccNum:  i + 1,
+	// next proxy from prox on that isn't benched, prox itself if they all are
+	pickProxy := func(at time.Time) int {
+		for n := 0; n < len(proxies); n++ {
+			idx := (prox + n) % len(proxies)
+			if s.throttle.proxyReady(proxies[idx], at) {
+				return idx
 			}
-			time.Sleep(time.Millisecond * time.Duration(sleepTime))
-			prox++
 		}
-		i++
+		return prox
 	}
 
+	next := 0 // index of the next offset of the plan
+	for {
+		if next >= len(plan.Offsets) {
+			if plan.Repeat <= 0 {
+				return
+			}
+			base, next = base.Add(plan.Repeat), 0
+		}
+
+		sendAt := base.Add(plan.Offsets[next])
+		next++
+		if !endTime.IsZero() && !sendAt.Before(endTime) {
+			return
+		}
+		spin := time.Duration(0)
+		if sendAt.Before(precise) {
+			spin = spinMargin
+		}
+		waitUntil(sendAt, spin)
+
+		// a repeating plan starts over from here, a finite one picks up where it is relative to the start
+		if rebalance(sendAt) {
+			if plan.Repeat > 0 {
+				base, next = sendAt, 0
+			} else {
+				next = sort.Search(len(plan.Offsets), func(k int) bool { return !base.Add(plan.Offsets[k]).Before(sendAt) })
+			}
+			continue
+		}
+
+		if y >= loopCount { // run n times / bearer
+			y = 0
+			i++
+		}
+		if i >= len(accounts) {
+			i = 0
+		}
+		if prox >= len(proxies) {
+			prox = 0
+		}
+
+		idx := pick(sendAt)
+		if idx == -1 {
+			if !starved {
+				log.Log("warn", "no %v account has ratelimit budget left, skipping sends until one does", accType)
+				starved = true
+			}
+			continue
+		}
+		starved = false
+		if idx != i {
+			i, y = idx, 0
+		}
+		var send *proxy.Proxy
+		if bound {
+			send = accounts[i].Proxy
+		} else {
+			prox = pickProxy(sendAt)
+			send = proxies[prox]
+		}
+
+		bearer, ok := s.quarantine.bearer(accounts[i])
+		if !ok {
+			continue
+		}
+
+		if !s.workers.submit(ClaimAttempt{
+			Claim:   s,
+			Name:    s.Username,
+			Bearer:  bearer,
+			AccType: accType,
+			Proxy:   send,
+			AccNum:  i + 1,
+			Account: accounts[i],
+
+			Scheduled:  sendAt,
+			Dispatched: time.Now(),
+		}) {
+			return
+		}
+
+		// the planned time keeps the ledger exact, a late send never eats into the next window
+		s.ledger.Record(accounts[i], sendAt)
+
+		y++
+		prox++
+	}
 }
 
-func claimName(claim ClaimAttempt, client *fasthttp.Client) {
+func claimName(claim ClaimAttempt, pool *clientPool) {
 	acc := mc.MCaccount{
 		Bearer: claim.Bearer,
 		Type:   claim.AccType,
@@ -121,137 +239,236 @@ func claimName(claim ClaimAttempt, client *fasthttp.Client) {
 	var err error = nil
 	var fail mc.FailType = mc.DUPLICATE
 
-	if strings.HasPrefix(claim.Proxy, "socks5://") {
-		client.Dial = fasthttpproxy.FasthttpSocksDialer(claim.Proxy)
-	} else if claim.Proxy != "" {
-		client.Dial = fasthttpproxy.FasthttpHTTPDialer(claim.Proxy)
-	}
+	client := pool.get(claim.Proxy)
 
 	before := time.Now()
-	if claim.AccType == mc.Ms {
+	if changesName(claim) {
 		status, fail, err = acc.ChangeUsername(claim.Name, client)
 	} else {
 		status, fail, err = acc.CreateProfile(claim.Name, client)
 	}
 	after := time.Now()
 
+	timing := Timing{Scheduled: claim.Scheduled, Dispatched: claim.Dispatched, Sent: before}
+	if err == nil {
+		timing.Answered = after
+	}
+	claim.Claim.timings.record(timing)
+
 	if err != nil {
-		log.Log("err", "%v #%d", err, claim.AccNum)
+		log.Log("err", "%v #%d via %v", err, claim.AccNum, claim.Proxy.Redacted())
 		return
 	}
 
-	Stats.Total++
+	updateStats(func(st *StatsStore) {
+		st.Total++
+		st.Types[claim.AccType].Total++
+		switch fail {
+		case mc.DUPLICATE:
+			st.Duplicate++
+		case mc.NOT_ALLOWED:
+			st.NotAllowed++
+		case mc.TOO_MANY_REQUESTS:
+			st.TooManyRequests++
+			st.Types[claim.AccType].TooManyRequests++
+		}
+		if status == 401 {
+			st.Unauthorized++
+		}
+	})
+
+	if fail == mc.TOO_MANY_REQUESTS {
+		claim.Claim.throttle.tooManyRequests(claim, claim.Claim.ledger, before)
+		if claim.Account != nil {
+			claim.Claim.quarantine.record(claim.Account, failTooManyRequests)
+		}
+	} else {
+		claim.Claim.throttle.answered(claim.Proxy)
+	}
+
+	if status == 401 {
+		claim.Claim.unauthorized(claim)
+	} else if fail == mc.NOT_ENTITLED {
+		claim.Claim.notEntitled(claim)
+	}
 
 	log.Log("info", "[%v] %v %vms %v %v #%d | %s", claim.Name, after.Format("15:04:05.999"), after.Sub(before).Milliseconds(), log.PrettyStatus(status), acc.Type, claim.AccNum, string(fail))
 	if status == 200 {
-		log.Log("success", "Claimed %v on %v acc, %v", claim.Name, acc.Type, acc.Bearer[len(acc.Bearer)/2:])
-		log.Log("success", "Join https://discord.gg/2BZseKW for more!")
-		Stats.Success++
-		claim.Claim.Running = false
+		claim.Claim.succeeded(claim, after)
 	}
+}
 
-	switch fail {
-	case mc.DUPLICATE:
-		Stats.Duplicate++
-	case mc.NOT_ALLOWED:
-		Stats.NotAllowed++
-	case mc.TOO_MANY_REQUESTS:
-		Stats.TooManyRequests++
+// changesName is true when the attempt renames an existing profile rather than creating one.
+// game pass accounts only have a profile if one was created before they were licensed.
+func changesName(claim ClaimAttempt) bool {
+	if claim.AccType == mc.Ms {
+		return true
 	}
-
+	return claim.AccType == mc.MsGp && claim.Account != nil && claim.Account.Username != ""
 }
 
-func worker(claimChan chan ClaimAttempt, killChan chan bool) {
-	client := &fasthttp.Client{
-		Dial: fasthttp.Dial,
+// availabilityChanged stops the claim once someone else takes the name after it became available.
+// a name that is taken at the first check is still held by its old owner before the drop.
+// a change seen while one of our 200s is being confirmed waits for the result, it may be our own claim.
+func (s *Claim) availabilityChanged(change availability.Change) {
+	if change.From != availability.Available || change.To != availability.Taken {
+		return
 	}
-	for {
-		select {
-		case claim := <-claimChan:
-			claimName(claim, client)
-		case <-killChan:
-			return
-		}
+
+	if s.pending.hold(change) {
+		return
 	}
+
+	if claimed := s.claimed(); claimed != nil && claimed.Account != nil && sameUUID(claimed.Account.UUID, change.UUID) {
+		return
+	}
+
+	emit("err", Event{Type: EventTaken, Name: s.Username}, "username %v is taken now", s.Username)
+	s.Running = false
 }
 
 func (s *Claim) runClaim() {
-	workChan := make(chan ClaimAttempt)
 	killChan := make(chan bool)
 	s.Running = true
 
-	go func() {
+	s.timings = newTimings()
+	updateStats(func(st *StatsStore) {
+		st.resetSends()
+		st.Drift, st.RoundTrip = s.timings.drift, s.timings.roundTrip
+	})
 
-		doChecks := true
-		_, statusCode, err := mc.UsernameToUuid(s.Username)
+	watcher := availability.NewWatcher(s.Options.WatchInterval, s.Username)
+	watcher.Subscribe(s.availabilityChanged)
+	watcher.Start()
+	defer watcher.Stop()
 
-		if err != nil {
-			log.Log("err", "failed to get uuid of %v for availability checking: %v", s.Username, err)
+	go func() {
+		for s.Running {
+			time.Sleep(time.Second * 2)
 		}
+		log.Log("info", "Stopped claim of %v", s.Username)
+		close(killChan)
+	}()
 
-		if statusCode != 404 {
-			doChecks = false
-		}
+	ledgerPath := s.Options.LedgerPath
+	if ledgerPath == "" {
+		ledgerPath = DefaultLedgerPath
+	}
 
-		for i := 0; true; i++ {
-			if i%30 == 0 && doChecks {
-				i = 0
-				_, statusCode, err = mc.UsernameToUuid(s.Username)
-
-				if err != nil {
-					log.Log("err", "failed to get uuid of %v for availability checking: %v", s.Username, err)
-				}
-
-				if statusCode == 200 {
-					log.Log("err", "username %v is taken now", s.Username)
-					s.Running = false
-					close(killChan)
-					return
-				}
-			}
+	ledger, err := LoadLedger(ledgerPath)
+	if err != nil {
+		log.Log("err", "failed to load request ledger %v, starting an empty one: %v", ledgerPath, err)
+	}
+	s.ledger = ledger
+	s.throttle = newThrottle()
+	s.quarantine = newQuarantine()
 
-			if !s.Running {
-				log.Log("info", "Stopped claim of %v", s.Username)
-				close(killChan)
-				return
-			}
-			time.Sleep(time.Second * 2)
+	defer func() {
+		if err := ledger.Save(); err != nil {
+			log.Log("err", "failed to save request ledger: %v", err)
 		}
 	}()
 
-	gcs := []string{}
-	mss := []string{}
+	go func() {
+		for s.Running {
+			time.Sleep(time.Second * 30)
+			ledger.Save()
+		}
+	}()
 
+	groups := map[mc.AccType][]*mc.MCaccount{}
 	for _, acc := range s.Accounts {
-		if acc.Type == mc.Ms {
-			mss = append(mss, acc.Bearer)
-		} else {
-			gcs = append(gcs, acc.Bearer)
-		}
+		groups[acc.Type] = append(groups[acc.Type], acc)
 	}
 
-	for i := 0; i < workerCount; i++ {
-		go worker(workChan, killChan)
+	if s.Options.ProxyPolicy == BindProxies {
+		s.Proxies = boundProxies(s.Accounts)
 	}
 
 	log.Log("info", "using %v accounts", len(s.Accounts))
-	log.Log("info", "using %v proxies", len(s.Proxies))
+	log.Log("info", "using %v proxies (%v)", len(s.Proxies), s.proxyPolicy())
 
 	if len(s.Proxies) == 0 {
-		s.Proxies = []string{""}
+		s.Proxies = []*proxy.Proxy{nil}
 	}
 
-	time.Sleep(time.Until(s.DropRange.Start))
+	pool := newClientPool(s.Proxies)
+	defer pool.close()
 
-	go requestGenerator(workChan, killChan, gcs, s.Username, mc.MsPr, s.DropRange.End, s.Proxies, -1)
-	go requestGenerator(workChan, killChan, mss, s.Username, mc.Ms, s.DropRange.End, s.Proxies, -1)
+	if s.Options.Prewarm > 0 && time.Until(s.DropRange.Start) > 0 {
+		s.prewarm(pool)
+	}
 
-	if s.DropRange.End.IsZero() {
-		select {}
+	scheduler := s.Options.Scheduler
+	if scheduler == nil {
+		scheduler = EvenSpread{}
 	}
 
-	for time.Now().Before(s.DropRange.End) {
-		time.Sleep(10 * time.Second)
+	// a drop that already started runs from now, so the plan isn't crammed into the time that's left
+	start := s.DropRange.Start
+	if start.Before(time.Now()) {
+		start = time.Now()
+	}
+
+	var window time.Duration
+	if !s.DropRange.End.IsZero() {
+		window = s.DropRange.End.Sub(start)
+	}
+
+	// every account type gets its own schedule, each type has its own endpoint and ratelimits
+	plans := map[mc.AccType]Plan{}
+	requests := map[mc.AccType]PlanRequest{}
+	for _, accType := range accTypes {
+		if len(groups[accType]) == 0 {
+			continue
+		}
+
+		groups[accType] = dropExhausted(ledger, groups[accType], start)
+		if len(groups[accType]) == 0 {
+			continue
+		}
+		requests[accType] = PlanRequest{Accounts: len(groups[accType]), Proxies: len(s.Proxies), AccType: accType, Window: window}
+		plans[accType] = scheduler.Plan(requests[accType])
+
+		warnShortfall(ledger, groups[accType], plans[accType], start)
+		logPlan(scheduler, accType, plans[accType])
+	}
+
+	workerCount := s.Options.Workers
+	if workerCount <= 0 {
+		rate := 0
+		for _, plan := range plans {
+			rate += peakRate(plan)
+		}
+
+		latency := s.latency
+		if calibrated := StatsSnapshot().Latency; latency == 0 && calibrated > 0 {
+			latency = calibrated * 2
+		}
+
+		workerCount = autoWorkers(rate, latency)
+		log.Log("info", "using up to %d workers (peak %d req/s)", workerCount, rate)
+	} else {
+		log.Log("info", "using up to %d workers", workerCount)
+	}
+
+	s.workers = newWorkerPool(workerCount, pool, killChan)
+
+	time.Sleep(time.Until(start))
+
+	sending := 0
+	for accType := range plans {
+		sending += len(groups[accType])
+	}
+	emit("info", Event{Type: EventStarted, Name: s.Username, Count: sending}, "snipe of %v started with %d account(s)", s.Username, sending)
+
+	for accType, plan := range plans {
+		go s.requestGenerator(groups[accType], accType, start, scheduler, requests[accType], plan)
+	}
+
+	// runs until the drop ends, forever for an infinite one, or until the name is claimed or the claim stopped
+	for s.Running && (s.DropRange.End.IsZero() || time.Now().Before(s.DropRange.End)) {
+		time.Sleep(time.Second)
 	}
 	s.Running = false
 	_, ok := (<-killChan)
@@ -259,4 +476,20 @@ func (s *Claim) runClaim() {
 		close(killChan)
 	}
 
+	s.workers.wait(time.Second * 10)
+	s.verifies.Wait()
+	s.logFailures()
+	s.logTimings()
+
+	stats := StatsSnapshot()
+	if stats.LateSends > 0 {
+		log.Log("warn", "%d send(s) went out late because every worker was busy", stats.LateSends)
+	}
+
+	if s.claimed() == nil {
+		emit("info", Event{Type: EventEnded, Name: s.Username, Count: stats.Total}, "snipe of %v ended without claiming it", s.Username)
+	}
+
+	s.postClaim()
+
 }
@@ -0,0 +1,68 @@
+package claimer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Kqzz/MCsniperGO/pkg/proxy"
+	"github.com/valyala/fasthttp"
+)
+
+type pooledClient struct {
+	*fasthttp.Client
+	proxy *proxy.Proxy
+}
+
+// clientPool holds one long lived client per proxy so every request goes out through the proxy it was assigned,
+// and keep-alive connections are reused across attempts instead of being thrown away on a dialer swap.
+type clientPool struct {
+	mu      sync.Mutex
+	clients map[string]*pooledClient
+}
+
+func newClientPool(proxies []*proxy.Proxy) *clientPool {
+	pool := &clientPool{clients: map[string]*pooledClient{}}
+	for _, p := range proxies {
+		pool.get(p)
+	}
+	return pool
+}
+
+func newClient(p *proxy.Proxy) *fasthttp.Client {
+	return &fasthttp.Client{
+		Dial:                p.Dialer(),
+		MaxIdleConnDuration: time.Minute,
+	}
+}
+
+// get returns the client for p, building it if this proxy hasn't been seen yet. nil is the direct client.
+func (pool *clientPool) get(p *proxy.Proxy) *fasthttp.Client {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	key := p.String()
+	client, ok := pool.clients[key]
+	if !ok {
+		client = &pooledClient{Client: newClient(p), proxy: p}
+		pool.clients[key] = client
+	}
+	return client.Client
+}
+
+// all returns a snapshot of every client in the pool
+func (pool *clientPool) all() []*pooledClient {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	clients := make([]*pooledClient, 0, len(pool.clients))
+	for _, client := range pool.clients {
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+func (pool *clientPool) close() {
+	for _, client := range pool.all() {
+		client.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,40 @@
+package claimer
+
+import (
+	"github.com/Kqzz/MCsniperGO/log"
+	"github.com/Kqzz/MCsniperGO/pkg/clock"
+	"github.com/Kqzz/MCsniperGO/pkg/mc"
+)
+
+// calibrateClock measures how far the local clock is off and shifts the drop range onto the local clock
+func calibrateClock(dropRange mc.DropRange, ntpServer string) mc.DropRange {
+	var cal clock.Calibration
+	var err error
+
+	if ntpServer != "" {
+		cal, err = clock.FromSNTP(ntpServer, 0)
+	} else {
+		cal, err = clock.FromHTTP(clock.DefaultUrl, 0, nil)
+	}
+
+	if err != nil {
+		log.Log("err", "failed to calibrate clock, using local time: %v", err)
+		return dropRange
+	}
+
+	updateStats(func(st *StatsStore) {
+		st.ClockOffset = cal.Offset
+		st.Latency = cal.Latency
+	})
+
+	log.Log("info", "clock offset %+dms, one-way latency ~%dms (%d samples from %v)", cal.Offset.Milliseconds(), cal.Latency.Milliseconds(), cal.Samples, cal.Source)
+
+	if !dropRange.Start.IsZero() {
+		dropRange.Start = cal.LocalTime(dropRange.Start)
+	}
+	if !dropRange.End.IsZero() {
+		dropRange.End = cal.LocalTime(dropRange.End)
+	}
+
+	return dropRange
+}
@@ -0,0 +1,90 @@
+package claimer
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Kqzz/MCsniperGO/log"
+	"github.com/Kqzz/MCsniperGO/pkg/mc"
+)
+
+type EventType string
+
+const (
+	EventCooldown     EventType = "cooldown"      // an account got a 429 and is resting for the rest of its window
+	EventProxyPenalty EventType = "proxy_penalty" // a proxy keeps getting 429s and is skipped for a while
+	EventRebalance    EventType = "rebalance"     // accounts started or finished cooling down and the rest of the drop was planned again for the ready ones
+	EventAuthed       EventType = "authed"        // accounts are authenticated, Count is how many are usable
+	EventStarted      EventType = "started"       // the drop window opened and sends began, Count is the accounts sending
+	EventEnded        EventType = "ended"         // the snipe is over without the name being claimed
+	EventError        EventType = "error"         // the snipe failed and stopped
+	EventTaken        EventType = "taken"         // someone else took the name, the claim stops
+	EventAvailable    EventType = "available"     // a watched name became available
+)
+
+// something that happened during a claim that other components may care about
+type Event struct {
+	Type    EventType
+	Time    time.Time
+	Name    string // username being claimed
+	AccType mc.AccType
+	AccNum  int
+	Proxy   string // redacted
+	Account string // redacted email or uuid of the account involved
+	Count   int
+	Message string
+}
+
+var (
+	subscribersMu sync.Mutex
+	subscribers   []func(Event)
+)
+
+// Subscribe registers fn to be called for every event. fn runs on the claimer's goroutines and should not block.
+func Subscribe(fn func(Event)) {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	subscribers = append(subscribers, fn)
+}
+
+// emit logs an event at level and hands it to every subscriber
+func emit(level string, e Event, message string, params ...interface{}) {
+	e.Message = fmt.Sprintf(message, params...)
+	log.Log(level, e.Message)
+	Publish(e)
+}
+
+// Publish hands e to every subscriber, for components outside the claimer that report through the same events
+func Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	subscribersMu.Lock()
+	subs := subscribers
+	subscribersMu.Unlock()
+
+	for _, fn := range subs {
+		fn(e)
+	}
+}
+
+// RedactAccount names acc without giving away its email
+func RedactAccount(acc *mc.MCaccount) string {
+	if acc == nil {
+		return ""
+	}
+	if at := strings.LastIndex(acc.Email, "@"); at > 0 {
+		keep := 2
+		if at < keep {
+			keep = at
+		}
+		return acc.Email[:keep] + "***" + acc.Email[at:]
+	}
+	if acc.UUID != "" {
+		return "uuid ..." + log.LastQuarter(acc.UUID)
+	}
+	return "bearer ..." + log.LastQuarter(acc.Bearer)
+}
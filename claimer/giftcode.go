@@ -0,0 +1,76 @@
+package claimer
+
+import (
+	"fmt"
+
+	"github.com/Kqzz/MCsniperGO/log"
+	"github.com/Kqzz/MCsniperGO/pkg/mc"
+	"github.com/Kqzz/MCsniperGO/pkg/parser"
+)
+
+// GiftCode is an account and the gift code to redeem onto it
+type GiftCode struct {
+	Account *mc.MCaccount
+	Code    string
+}
+
+// RedeemGiftCodes logs every account in and redeems its gift code, paced and retried like the logins before a snipe.
+// an account that owns minecraft but has no profile yet keeps its code, one with a profile fails with mc.ErrHasProfile.
+// progress goes to Stats.GiftCodes, results are in the order of codes.
+func RedeemGiftCodes(codes []GiftCode, opts AuthOptions) []AuthResult {
+	accounts := []*mc.MCaccount{}
+	byAccount := map[*mc.MCaccount]string{}
+	for _, gc := range codes {
+		accounts = append(accounts, gc.Account)
+		byAccount[gc.Account] = gc.Code
+	}
+
+	return runLogins("", accounts, opts, func(account *mc.MCaccount) error {
+		err := account.MicrosoftAuthenticate(account.Proxy)
+		if err != nil {
+			return err
+		}
+
+		// a retry after a redemption that went through but didn't answer lands here too
+		status, err := account.CheckEntitlement()
+		if err != nil {
+			return err
+		}
+		if status.HasProfile {
+			return fmt.Errorf("%w (%v), it can't be used as a GC account", mc.ErrHasProfile, status.Username)
+		}
+		if status.ReadyForProfile() {
+			log.Log("info", "%s already owns minecraft, not redeeming its code", accountName(account))
+			return nil
+		}
+
+		err = account.RedeemGiftCode(byAccount[account])
+		if err != nil {
+			return err
+		}
+		log.Log("success", "redeemed gift code onto %s", accountName(account))
+		return nil
+	}, func(st *StatsStore) *AuthProgress { return &st.GiftCodes })
+}
+
+// RedeemGiftCodeLines redeems the code of every line and returns the records of the accounts that are ready to snipe with
+func RedeemGiftCodeLines(lines []parser.GiftCodeLine, opts AuthOptions) ([]parser.AccountRecord, []error) {
+	codes, used, errs := []GiftCode{}, []parser.GiftCodeLine{}, []error{}
+	for _, line := range lines {
+		acc, err := line.Record.Account()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v: %v", line.Record.Email, err))
+			continue
+		}
+		codes = append(codes, GiftCode{Account: acc, Code: line.Code})
+		used = append(used, line)
+	}
+
+	ready := []parser.AccountRecord{}
+	for i, r := range RedeemGiftCodes(codes, opts) {
+		if r.Err == nil {
+			ready = append(ready, used[i].Record)
+		}
+	}
+	return ready, errs
+}
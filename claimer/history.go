@@ -0,0 +1,68 @@
+package claimer
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/Kqzz/MCsniperGO/pkg/mc"
+)
+
+const DefaultHistoryPath = "history.jsonl"
+
+// HistoryEntry is one claimed name as written to the history store
+type HistoryEntry struct {
+	Name      string     `json:"name"`
+	Account   string     `json:"account"` // uuid or email of the account that claimed it
+	AccType   mc.AccType `json:"accType"`
+	Proxy     string     `json:"proxy"` // redacted
+	ClaimedAt time.Time  `json:"claimedAt"`
+	Verified  bool       `json:"verified"` // the name was matched to the account's uuid after the claim
+}
+
+// AppendHistory adds entry to the history store at path, one json object per line
+func AppendHistory(path string, entry HistoryEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// LoadHistory reads every entry of the history store at path, oldest first
+func LoadHistory(path string) ([]HistoryEntry, error) {
+	entries := []HistoryEntry{}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return entries, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return entries, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,243 @@
+package claimer
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Kqzz/MCsniperGO/log"
+	"github.com/Kqzz/MCsniperGO/pkg/mc"
+	"github.com/Kqzz/MCsniperGO/pkg/proxy"
+)
+
+const (
+	EventClaimed   EventType = "claimed"    // a name was claimed
+	EventPostClaim EventType = "post_claim" // a post-claim step finished, Message says how it went
+)
+
+// Claimed is the attempt that got the name, handed to every post-claim step in turn
+type Claimed struct {
+	Name     string
+	Account  *mc.MCaccount
+	AccType  mc.AccType
+	AccNum   int
+	Proxy    *proxy.Proxy
+	At       time.Time
+	Verified bool // the name was seen on the account's profile and in a public lookup
+
+	claim *Claim
+}
+
+// PostClaimStep is something done with a name right after it was claimed
+type PostClaimStep interface {
+	Name() string
+	Run(claimed *Claimed) error
+}
+
+type StepResult struct {
+	Step string
+	Err  error
+	Took time.Duration
+}
+
+// Skin uploads a skin to the claiming account, from a url or a local png
+type Skin struct {
+	Source  string
+	Variant string // "classic" or "slim", classic if empty
+}
+
+func (s Skin) Name() string {
+	return "skin"
+}
+
+func (s Skin) Run(claimed *Claimed) error {
+	variant := s.Variant
+	if variant == "" {
+		variant = "classic"
+	}
+
+	if strings.HasPrefix(s.Source, "http://") || strings.HasPrefix(s.Source, "https://") {
+		return claimed.Account.ChangeSkinFromUrl(s.Source, variant)
+	}
+	return claimed.Account.ChangeSkinFromFile(s.Source, variant)
+}
+
+// Record writes the claim to the history store
+type Record struct {
+	Path string // DefaultHistoryPath if empty
+}
+
+func (r Record) Name() string {
+	return "record"
+}
+
+func (r Record) Run(claimed *Claimed) error {
+	path := r.Path
+	if path == "" {
+		path = DefaultHistoryPath
+	}
+
+	return AppendHistory(path, HistoryEntry{
+		Name:      claimed.Name,
+		Account:   accountKey(claimed.Account),
+		AccType:   claimed.AccType,
+		Proxy:     claimed.Proxy.Redacted(),
+		ClaimedAt: claimed.At,
+		Verified:  claimed.Verified,
+	})
+}
+
+// FollowUp snipes another name with the accounts that didn't claim, same proxies and options.
+// it isn't run with the rest of the pipeline but once the claim is over (see Claim.followUp).
+type FollowUp struct {
+	Username  string
+	DropRange mc.DropRange
+}
+
+func (f FollowUp) Name() string {
+	return "follow-up"
+}
+
+func (f FollowUp) Run(claimed *Claimed) error {
+	accounts := []*mc.MCaccount{}
+	for _, acc := range claimed.claim.Accounts {
+		if acc != claimed.Account {
+			accounts = append(accounts, acc)
+		}
+	}
+	if len(accounts) == 0 {
+		return errors.New("no accounts left to snipe with")
+	}
+
+	// a follow-up doesn't start another one
+	opts := claimed.claim.Options
+	opts.PostClaim = nil
+	for _, step := range claimed.claim.Options.PostClaim {
+		if _, ok := step.(FollowUp); !ok {
+			opts.PostClaim = append(opts.PostClaim, step)
+		}
+	}
+
+	return ClaimWithinRange(f.Username, f.DropRange, accounts, claimed.claim.Proxies, opts)
+}
+
+// PostClaimConfig is the flag and json friendly form of a pipeline
+type PostClaimConfig struct {
+	Skin          string // url or local png, empty to keep the current skin
+	SkinVariant   string
+	HistoryPath   string // empty to not record claims
+	FollowUp      string // name to snipe next with the accounts that are left, empty for none
+	FollowUpRange mc.DropRange
+}
+
+// Steps lists the configured steps, the follow-up last
+func (c PostClaimConfig) Steps() []PostClaimStep {
+	steps := []PostClaimStep{}
+	if c.Skin != "" {
+		steps = append(steps, Skin{Source: c.Skin, Variant: c.SkinVariant})
+	}
+	if c.HistoryPath != "" {
+		steps = append(steps, Record{Path: c.HistoryPath})
+	}
+	if c.FollowUp != "" {
+		steps = append(steps, FollowUp{Username: c.FollowUp, DropRange: c.FollowUpRange})
+	}
+	return steps
+}
+
+type winner struct {
+	mu      sync.Mutex
+	claimed *Claimed
+}
+
+// won keeps the first successful attempt of the claim for the post-claim pipeline
+func (s *Claim) won(claim ClaimAttempt, at time.Time, verified bool) {
+	s.winner.mu.Lock()
+	defer s.winner.mu.Unlock()
+
+	if s.winner.claimed != nil {
+		return
+	}
+	s.winner.claimed = &Claimed{
+		Name:     claim.Name,
+		Account:  claim.Account,
+		AccType:  claim.AccType,
+		AccNum:   claim.AccNum,
+		Proxy:    claim.Proxy,
+		At:       at,
+		Verified: verified,
+		claim:    s,
+	}
+}
+
+// claimed returns the attempt that got the name, nil if none did yet
+func (s *Claim) claimed() *Claimed {
+	s.winner.mu.Lock()
+	defer s.winner.mu.Unlock()
+	return s.winner.claimed
+}
+
+// postClaim runs every configured step but the follow-up against the winning attempt, reporting each as it finishes
+func (s *Claim) postClaim() []StepResult {
+	claimed := s.claimed()
+	if claimed == nil {
+		return nil
+	}
+
+	results := []StepResult{}
+	for _, step := range s.Options.PostClaim {
+		if _, ok := step.(FollowUp); ok {
+			continue
+		}
+		results = append(results, runStep(claimed, step))
+	}
+	if len(results) == 0 {
+		return nil
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+	log.Log("info", "post-claim pipeline for %v: %d/%d steps succeeded", claimed.Name, len(results)-failed, len(results))
+
+	return results
+}
+
+// followUp runs the follow-up snipe once the claim is over, so its stats and the caller's
+// view of it are complete before the next name takes over
+func (s *Claim) followUp() {
+	claimed := s.claimed()
+	if claimed == nil {
+		return
+	}
+
+	for _, step := range s.Options.PostClaim {
+		if _, ok := step.(FollowUp); ok {
+			runStep(claimed, step)
+		}
+	}
+}
+
+func runStep(claimed *Claimed, step PostClaimStep) StepResult {
+	event := Event{Type: EventPostClaim, Name: claimed.Name, AccType: claimed.AccType, AccNum: claimed.AccNum, Proxy: claimed.Proxy.Redacted()}
+
+	if claimed.Account == nil {
+		emit("err", event, "post-claim %v skipped: no account to run on", step.Name())
+		return StepResult{Step: step.Name(), Err: errors.New("no account to run on")}
+	}
+
+	started := time.Now()
+	err := step.Run(claimed)
+	result := StepResult{Step: step.Name(), Err: err, Took: time.Since(started)}
+
+	if err != nil {
+		emit("err", event, "post-claim %v failed: %v", step.Name(), err)
+	} else {
+		emit("success", event, "post-claim %v done in %v", step.Name(), result.Took.Round(time.Millisecond))
+	}
+	return result
+}
@@ -0,0 +1,139 @@
+package claimer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Kqzz/MCsniperGO/log"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	warmUrl           = "https://api.minecraftservices.com/"
+	warmTimeout       = time.Second * 10
+	keepAliveInterval = time.Second * 15
+)
+
+type warmResult struct {
+	client  *pooledClient
+	opened  int
+	slowest time.Duration // slowest full request seen, on a cold client this includes dns, tcp and tls setup
+	err     error
+}
+
+// warmClient fires conns concurrent requests through client so it ends up holding conns idle keep-alive connections
+func warmClient(client *pooledClient, conns int, timeout time.Duration) warmResult {
+	result := warmResult{client: client}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < conns; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			req := fasthttp.AcquireRequest()
+			resp := fasthttp.AcquireResponse()
+			defer fasthttp.ReleaseRequest(req)
+			defer fasthttp.ReleaseResponse(resp)
+
+			req.SetRequestURI(warmUrl)
+			req.Header.SetMethod("GET")
+
+			before := time.Now()
+			err := client.DoTimeout(req, resp, timeout)
+			took := time.Since(before)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.err = err
+				return
+			}
+			result.opened++
+			if took > result.slowest {
+				result.slowest = took
+			}
+		}()
+	}
+	wg.Wait()
+
+	return result
+}
+
+// warm opens conns connections on every client in the pool at once, giving each request up to timeout
+func (pool *clientPool) warm(conns int, timeout time.Duration) []warmResult {
+	clients := pool.all()
+	results := make([]warmResult, len(clients))
+
+	var wg sync.WaitGroup
+	for i, client := range clients {
+		wg.Add(1)
+		go func(i int, client *pooledClient) {
+			defer wg.Done()
+			results[i] = warmClient(client, conns, timeout)
+		}(i, client)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// untilDrop caps timeout so warming never runs into the drop, 0 once it started
+func (s *Claim) untilDrop(timeout time.Duration) time.Duration {
+	if until := time.Until(s.DropRange.Start); until < timeout {
+		timeout = until
+	}
+	if timeout < 0 {
+		return 0
+	}
+	return timeout
+}
+
+// prewarm waits until Options.Prewarm before the drop, opens connections on every proxy client
+// and keeps them alive until the drop starts so the first requests skip connection setup.
+// no warming request outlives the drop start, so a slow proxy can't push the plan back.
+func (s *Claim) prewarm(pool *clientPool) {
+	warmAt := s.DropRange.Start.Add(-s.Options.Prewarm)
+	time.Sleep(time.Until(warmAt))
+
+	conns := s.Options.PrewarmConns
+	if conns < 1 {
+		conns = 4
+	}
+
+	timeout := s.untilDrop(warmTimeout)
+	if timeout == 0 {
+		return
+	}
+
+	log.Log("info", "warming %d connection(s) per proxy", conns)
+
+	var total time.Duration
+	warmed := 0
+	for _, r := range pool.warm(conns, timeout) {
+		if r.err != nil {
+			log.Log("err", "failed to warm %v: %v", r.client.proxy.Redacted(), r.err)
+		}
+		if r.opened == 0 {
+			continue
+		}
+		log.Log("info", "warmed %d connection(s) via %v, cold request ~%vms", r.opened, r.client.proxy.Redacted(), r.slowest.Milliseconds())
+		total += r.slowest
+		warmed++
+	}
+
+	if warmed > 0 {
+		s.latency = total / time.Duration(warmed)
+		log.Log("success", "warmed %d proxies, average cold request ~%vms", warmed, s.latency.Milliseconds())
+	}
+
+	go func() {
+		for time.Until(s.DropRange.Start) > keepAliveInterval {
+			time.Sleep(keepAliveInterval)
+			if timeout := s.untilDrop(warmTimeout); timeout > 0 {
+				pool.warm(conns, timeout)
+			}
+		}
+	}()
+}
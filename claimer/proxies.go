@@ -0,0 +1,40 @@
+package claimer
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Kqzz/MCsniperGO/log"
+	"github.com/Kqzz/MCsniperGO/pkg/proxy"
+)
+
+// checkProxies drops dead (and optionally slow) proxies and orders the rest fastest first,
+// along with the order requests should rotate through them in so the faster ones get more of them
+func checkProxies(proxies []*proxy.Proxy, target string, maxLatency time.Duration) ([]*proxy.Proxy, []*proxy.Proxy, error) {
+	log.Log("info", "checking %d proxies", len(proxies))
+
+	results := proxy.NewChecker(target).CheckAll(proxies)
+
+	for _, r := range results {
+		if !r.Alive {
+			log.Log("err", "dead proxy %v: %v", r.Proxy.Redacted(), r.Err)
+		} else if maxLatency > 0 && r.Latency() > maxLatency {
+			log.Log("warn", "slow proxy %v: %vms", r.Proxy.Redacted(), r.Latency().Milliseconds())
+		}
+	}
+
+	usable := []proxy.CheckResult{}
+	for _, r := range results {
+		if maxLatency == 0 || r.Latency() <= maxLatency {
+			usable = append(usable, r)
+		}
+	}
+	ranked := proxy.Rank(usable)
+
+	if len(ranked) == 0 {
+		return nil, nil, errors.New("no proxies passed the health check")
+	}
+
+	log.Log("success", "using %d/%d proxies", len(ranked), len(proxies))
+	return ranked, proxy.Rotation(usable), nil
+}
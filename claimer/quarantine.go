@@ -0,0 +1,166 @@
+package claimer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Kqzz/MCsniperGO/log"
+	"github.com/Kqzz/MCsniperGO/pkg/mc"
+)
+
+const (
+	EventQuarantine EventType = "quarantine" // an account got a 401 and was pulled out of rotation
+	EventReauth     EventType = "reauth"     // a quarantined account was re-authenticated and is back in rotation
+	EventRemoved    EventType = "removed"    // an account can never claim and was dropped for good
+)
+
+type failClass string
+
+const (
+	failUnauthorized    failClass = "unauthorized"
+	failNotEntitled     failClass = "not_entitled"
+	failTooManyRequests failClass = "too_many_requests"
+)
+
+// quarantine pulls accounts that stop working out of rotation. a 401 benches the account until it's
+// re-authenticated in the background, NOT_ENTITLED removes it for the rest of the claim.
+type quarantine struct {
+	mu       sync.Mutex
+	out      map[*mc.MCaccount]failClass
+	failures map[*mc.MCaccount]map[failClass]int
+}
+
+func newQuarantine() *quarantine {
+	return &quarantine{
+		out:      map[*mc.MCaccount]failClass{},
+		failures: map[*mc.MCaccount]map[failClass]int{},
+	}
+}
+
+// bearer returns the current bearer of acc, false when it's out of rotation
+func (q *quarantine) bearer(acc *mc.MCaccount) (string, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, out := q.out[acc]; out {
+		return "", false
+	}
+	return acc.Bearer, true
+}
+
+func (q *quarantine) inRotation(acc *mc.MCaccount) bool {
+	_, ok := q.bearer(acc)
+	return ok
+}
+
+// record counts a failure of class against acc, returning true the first time it takes acc out of rotation
+func (q *quarantine) record(acc *mc.MCaccount, class failClass) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.failures[acc] == nil {
+		q.failures[acc] = map[failClass]int{}
+	}
+	q.failures[acc][class]++
+
+	if class == failTooManyRequests {
+		return false
+	}
+
+	if current, out := q.out[acc]; out && (current == failNotEntitled || current == class) {
+		return false
+	}
+	q.out[acc] = class
+	return true
+}
+
+// failuresOf returns how many failures of each class acc has had
+func (q *quarantine) failuresOf(acc *mc.MCaccount) map[failClass]int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	counts := map[failClass]int{}
+	for class, n := range q.failures[acc] {
+		counts[class] = n
+	}
+	return counts
+}
+
+func (s *Claim) unauthorized(claim ClaimAttempt) {
+	acc := claim.Account
+	if acc == nil || !s.quarantine.record(acc, failUnauthorized) {
+		return
+	}
+
+	updateStats(func(st *StatsStore) { st.Quarantined++ })
+	event := Event{Type: EventQuarantine, Name: claim.Name, AccType: claim.AccType, AccNum: claim.AccNum, Proxy: claim.Proxy.Redacted(), Account: RedactAccount(acc)}
+
+	if acc.Email == "" || acc.Password == "" || acc.Password == "code" {
+		emit("err", event, "account #%d (%v) got 401 and has no credentials to re-auth with, pulled out of rotation", claim.AccNum, claim.AccType)
+		return
+	}
+
+	emit("warn", event, "account #%d (%v) got 401, pulled out of rotation while it re-authenticates", claim.AccNum, claim.AccType)
+
+	go s.reauth(claim)
+}
+
+// reauth logs acc back in and returns it to rotation, leaving it out if that fails
+func (s *Claim) reauth(claim ClaimAttempt) {
+	acc := claim.Account
+	event := Event{Type: EventReauth, Name: claim.Name, AccType: claim.AccType, AccNum: claim.AccNum}
+
+	fresh := &mc.MCaccount{Email: acc.Email, Password: acc.Password, Type: acc.Type, Proxy: acc.Proxy}
+	fresh.DefaultFastHttpHandler()
+	useBoundProxy(fresh)
+
+	err := fresh.MicrosoftAuthenticate(fresh.Proxy)
+	if err == nil && fresh.Type == mc.MsGp {
+		err = fresh.License()
+	}
+
+	if err != nil {
+		emit("err", event, "failed to re-authenticate account #%d (%v), leaving it out: %v", claim.AccNum, claim.AccType, err)
+		return
+	}
+
+	s.quarantine.mu.Lock()
+	acc.Bearer = fresh.Bearer
+	if s.quarantine.out[acc] == failUnauthorized {
+		delete(s.quarantine.out, acc)
+	}
+	s.quarantine.mu.Unlock()
+
+	emit("success", event, "re-authenticated account #%d (%v), back in rotation", claim.AccNum, claim.AccType)
+}
+
+func (s *Claim) notEntitled(claim ClaimAttempt) {
+	acc := claim.Account
+	if acc == nil || !s.quarantine.record(acc, failNotEntitled) {
+		return
+	}
+
+	updateStats(func(st *StatsStore) { st.Removed++ })
+	event := Event{Type: EventRemoved, Name: claim.Name, AccType: claim.AccType, AccNum: claim.AccNum, Proxy: claim.Proxy.Redacted(), Account: RedactAccount(acc)}
+	emit("err", event, "account #%d (%v) is NOT_ENTITLED, removed for the rest of the claim", claim.AccNum, claim.AccType)
+}
+
+// logFailures prints the failure classes each account ran into over the claim
+func (s *Claim) logFailures() {
+	for _, acc := range s.Accounts {
+		counts := s.quarantine.failuresOf(acc)
+		if len(counts) == 0 {
+			continue
+		}
+
+		parts := []string{}
+		for class, n := range counts {
+			parts = append(parts, fmt.Sprintf("%v x%d", class, n))
+		}
+		sort.Strings(parts)
+
+		log.Log("info", "%v (%v): %v", accountKey(acc), acc.Type, strings.Join(parts, ", "))
+	}
+}
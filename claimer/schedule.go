@@ -0,0 +1,359 @@
+package claimer
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kqzz/MCsniperGO/log"
+	"github.com/Kqzz/MCsniperGO/pkg/mc"
+	"github.com/Kqzz/MCsniperGO/pkg/parser"
+)
+
+// ratelimits are set up as follows - 3 requests / 30s (2/30s for giftcard and game pass accounts), 40 requests / 24h.
+// game pass accounts share the giftcard numbers but are scheduled and tracked on their own.
+// schedulers are expected to stay within both unless told otherwise (fixed and custom schedules do what they're told)
+type rateLimit struct {
+	perShort int
+	short    time.Duration
+	perLong  int
+	long     time.Duration
+}
+
+func limitsFor(accType mc.AccType) rateLimit {
+	limit := rateLimit{perShort: 2, short: time.Second * 30, perLong: 40, long: time.Hour * 24}
+	if accType == mc.Ms {
+		limit.perShort = 3
+	}
+	return limit
+}
+
+// what a scheduler gets to work with
+type PlanRequest struct {
+	Accounts int
+	Proxies  int
+	AccType  mc.AccType
+	Window   time.Duration // length of the drop range, 0 when it has no end
+}
+
+// senders is how many accounts can actually be kept busy, with fewer proxies than accounts the proxies are the bottleneck
+func (r PlanRequest) senders() int {
+	n := r.Accounts
+	if r.Proxies > 0 && r.Proxies < n {
+		n = r.Proxies
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// a planned timeline of sends
+type Plan struct {
+	Offsets []time.Duration // send times relative to the start of the drop, ascending
+	Repeat  time.Duration   // when > 0, the offsets are replayed every Repeat until the drop ends
+}
+
+type Scheduler interface {
+	Name() string
+	Plan(req PlanRequest) Plan
+}
+
+// spaces requests evenly so no account goes over its short or long ratelimit
+type EvenSpread struct{}
+
+func (EvenSpread) Name() string { return "even" }
+
+func (EvenSpread) Plan(req PlanRequest) Plan {
+	interval := evenInterval(req)
+
+	if req.Window == 0 {
+		return Plan{Offsets: []time.Duration{0}, Repeat: interval}
+	}
+
+	return fixedPlan(interval, req.Window)
+}
+
+func evenInterval(req PlanRequest) time.Duration {
+	limit := limitsFor(req.AccType)
+	n := time.Duration(req.senders())
+
+	// if under ratelimit periods for our drop range, we should use the drop range instead of the ratelimit period
+	short, long := limit.short, limit.long
+	if req.Window != 0 && req.Window < short {
+		short = req.Window
+	}
+	if req.Window != 0 && req.Window < long {
+		long = req.Window
+	}
+
+	deltaShort := short / time.Duration(limit.perShort) / n
+	deltaLong := long / time.Duration(limit.perLong) / n
+
+	// take the higher of the two
+	interval := deltaShort
+	if deltaLong > interval {
+		interval = deltaLong
+	}
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+	return interval
+}
+
+// maxOffsets caps how many sends a plan spells out, past it a steady pace is repeated instead
+const maxOffsets = 10000
+
+// fixedCount is how many sends fit in window one interval apart
+func fixedCount(interval, window time.Duration) int {
+	return int((window + interval - 1) / interval)
+}
+
+// fixedPlan sends every interval over window, as a repeat when listing them would take more than maxOffsets
+func fixedPlan(interval, window time.Duration) Plan {
+	if fixedCount(interval, window) > maxOffsets {
+		return Plan{Offsets: []time.Duration{0}, Repeat: interval}
+	}
+
+	offsets := []time.Duration{}
+	for t := time.Duration(0); t < window; t += interval {
+		offsets = append(offsets, t)
+	}
+	return Plan{Offsets: offsets}
+}
+
+// spends every account's short window budget right at the start, then waits for the budget to come back
+type Burst struct {
+	Gap time.Duration // spacing between requests inside a burst
+}
+
+func (Burst) Name() string { return "burst" }
+
+func (b Burst) Plan(req PlanRequest) Plan {
+	limit := limitsFor(req.AccType)
+	perBurst := req.senders() * limit.perShort
+
+	burst := make([]time.Duration, perBurst)
+	for i := range burst {
+		burst[i] = time.Duration(i) * b.Gap
+	}
+
+	// a burst per short window, unless that would go over the daily limit
+	period := limit.short
+	if daily := limit.long * time.Duration(limit.perShort) / time.Duration(limit.perLong); daily > period {
+		period = daily
+	}
+
+	if req.Window == 0 {
+		return Plan{Offsets: burst, Repeat: period}
+	}
+
+	offsets := []time.Duration{}
+	for base := time.Duration(0); base < req.Window; base += period {
+		for _, off := range burst {
+			if base+off < req.Window {
+				offsets = append(offsets, base+off)
+			}
+		}
+	}
+	return Plan{Offsets: offsets}
+}
+
+// sends the same number of requests as EvenSpread, but packs them towards the start of the window
+type FrontLoaded struct {
+	Power float64 // how hard requests are pushed forward, 2 if unset
+}
+
+func (FrontLoaded) Name() string { return "decay" }
+
+func (f FrontLoaded) Plan(req PlanRequest) Plan {
+	power := f.Power
+	if power <= 1 {
+		power = 2
+	}
+
+	limit := limitsFor(req.AccType)
+
+	horizon := req.Window
+	if horizon == 0 {
+		horizon = limit.long
+	}
+
+	count := fixedCount(evenInterval(PlanRequest{
+		Accounts: req.Accounts,
+		Proxies:  req.Proxies,
+		AccType:  req.AccType,
+		Window:   horizon,
+	}), horizon)
+	if count > maxOffsets {
+		count = maxOffsets
+	}
+
+	offsets := make([]time.Duration, count)
+	for k := range offsets {
+		offsets[k] = time.Duration(float64(horizon) * math.Pow(float64(k)/float64(count), power))
+	}
+
+	offsets = clampShortWindow(offsets, req.senders()*limit.perShort, limit.short)
+
+	plan := Plan{}
+	for _, off := range offsets {
+		if off < horizon {
+			plan.Offsets = append(plan.Offsets, off)
+		}
+	}
+	if req.Window == 0 {
+		plan.Repeat = horizon
+	}
+	return plan
+}
+
+// clampShortWindow pushes offsets back so no short window holds more than perWindow sends
+func clampShortWindow(offsets []time.Duration, perWindow int, window time.Duration) []time.Duration {
+	for k := perWindow; k < len(offsets); k++ {
+		if earliest := offsets[k-perWindow] + window; offsets[k] < earliest {
+			offsets[k] = earliest
+		}
+	}
+	return offsets
+}
+
+// sends one request every Interval regardless of ratelimits
+type FixedInterval struct {
+	Interval time.Duration
+}
+
+func (FixedInterval) Name() string { return "fixed" }
+
+func (f FixedInterval) Plan(req PlanRequest) Plan {
+	interval := f.Interval
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+
+	if req.Window == 0 {
+		return Plan{Offsets: []time.Duration{0}, Repeat: interval}
+	}
+
+	return fixedPlan(interval, req.Window)
+}
+
+// sends at a user supplied list of offsets, once
+type CustomOffsets struct {
+	Offsets []time.Duration
+}
+
+func (CustomOffsets) Name() string { return "custom" }
+
+func (c CustomOffsets) Plan(req PlanRequest) Plan {
+	plan := Plan{}
+	for _, off := range c.Offsets {
+		if req.Window == 0 || off < req.Window {
+			plan.Offsets = append(plan.Offsets, off)
+		}
+	}
+	return plan
+}
+
+// parseOffset reads a single offset, plain numbers are milliseconds
+func parseOffset(s string) (time.Duration, error) {
+	if ms, err := strconv.Atoi(s); err == nil {
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// LoadOffsets reads one offset per line (150, 150ms, 1.5s), relative to the start of the drop
+func LoadOffsets(filename string) ([]time.Duration, error) {
+	lines, err := parser.ReadLines(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := []time.Duration{}
+	prevLine := 0
+	for i, l := range lines {
+		l = strings.TrimSpace(l)
+		if l == "" || l[0] == '#' {
+			continue
+		}
+
+		off, err := parseOffset(l)
+		if err != nil {
+			return nil, fmt.Errorf("invalid offset on line %v: %v", i+1, err)
+		}
+		if len(offsets) > 0 && off < offsets[len(offsets)-1] {
+			return nil, fmt.Errorf("offsets must be ascending, line %v goes back from line %v", i+1, prevLine)
+		}
+		offsets = append(offsets, off)
+		prevLine = i + 1
+	}
+
+	return offsets, nil
+}
+
+// ParseScheduler turns even, burst, decay, fixed:<interval> or file:<path> into a Scheduler
+func ParseScheduler(spec string) (Scheduler, error) {
+	kind, arg := spec, ""
+	if i := strings.Index(spec, ":"); i != -1 {
+		kind, arg = spec[:i], spec[i+1:]
+	}
+
+	switch kind {
+	case "", "even":
+		return EvenSpread{}, nil
+	case "burst":
+		b := Burst{}
+		if arg != "" {
+			gap, err := parseOffset(arg)
+			if err != nil {
+				return nil, err
+			}
+			b.Gap = gap
+		}
+		return b, nil
+	case "decay":
+		f := FrontLoaded{}
+		if arg != "" {
+			power, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return nil, err
+			}
+			f.Power = power
+		}
+		return f, nil
+	case "fixed":
+		interval, err := parseOffset(arg)
+		if err != nil {
+			return nil, fmt.Errorf("fixed schedule needs an interval: %v", err)
+		}
+		return FixedInterval{Interval: interval}, nil
+	case "file":
+		offsets, err := LoadOffsets(arg)
+		if err != nil {
+			return nil, err
+		}
+		return CustomOffsets{Offsets: offsets}, nil
+	}
+
+	return nil, fmt.Errorf("unknown schedule %v", kind)
+}
+
+// logPlan prints the timeline a scheduler came up with before anything is sent
+func logPlan(s Scheduler, accType mc.AccType, plan Plan) {
+	if len(plan.Offsets) == 0 {
+		log.Log("warn", "%v schedule %v has no requests planned", accType, s.Name())
+		return
+	}
+
+	first, last := plan.Offsets[0], plan.Offsets[len(plan.Offsets)-1]
+
+	if plan.Repeat > 0 {
+		log.Log("info", "%v schedule %v: %d request(s) between +%v and +%v, repeating every %v", accType, s.Name(), len(plan.Offsets), first, last, plan.Repeat)
+		return
+	}
+
+	log.Log("info", "%v schedule %v: %d request(s) between +%v and +%v", accType, s.Name(), len(plan.Offsets), first, last)
+}
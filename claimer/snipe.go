@@ -3,10 +3,12 @@ package claimer
 import (
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/Kqzz/MCsniperGO/log"
 	"github.com/Kqzz/MCsniperGO/pkg/mc"
+	"github.com/Kqzz/MCsniperGO/pkg/proxy"
 	"github.com/gookit/color"
 )
 
@@ -16,7 +18,29 @@ type StatsStore struct {
 	Duplicate       int
 	NotAllowed      int
 	Success         int
+	Cooldowns       int // accounts benched after a 429
+	ProxyPenalties  int // proxies benched after repeated 429s
+	Unauthorized    int // 401 responses
+	Quarantined     int // accounts pulled out of rotation after a 401
+	Removed         int // accounts dropped for being NOT_ENTITLED
+	LateSends       int // sends delayed because every worker was busy
+	Unconfirmed     int // 200s that verification showed didn't claim the name
 	StartTime       time.Time
+	ClockOffset     time.Duration // remote clock minus local clock, from the last calibration
+	Latency         time.Duration // one-way latency measured during calibration
+	Warnings        []string      // problems found while planning the snipe
+	Drift           *Histogram    // how late each send of the current snipe went out, nil before one starts
+	RoundTrip       *Histogram    // send to response time of each request of the current snipe
+	Auth            AuthProgress  // logins of the current snipe
+	GiftCodes       AuthProgress  // the latest gift code redemptions, kept across snipes
+	Types           map[mc.AccType]*TypeStats
+}
+
+// the part of the stats that is kept per account type
+type TypeStats struct {
+	Total           int
+	Success         int
+	TooManyRequests int
 }
 
 const (
@@ -24,13 +48,122 @@ const (
 	spread     = 0
 )
 
-var Stats StatsStore
+// warnings kept for the stats, older ones are dropped past this
+const maxWarnings = 50
+
+// Stats is written by concurrent workers, read it through StatsSnapshot while a snipe runs
+var Stats = StatsStore{
+	Types: newTypeStats(),
+}
+
+var statsMu sync.Mutex
+
+// updateStats runs f with the stats locked
+func updateStats(f func(st *StatsStore)) {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	f(&Stats)
+}
+
+// StatsSnapshot is a copy of the stats that is safe to read while a snipe updates them
+func StatsSnapshot() StatsStore {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+
+	snapshot := Stats
+	snapshot.Warnings = append([]string(nil), Stats.Warnings...)
+	snapshot.Auth.Results = append([]AuthResult(nil), Stats.Auth.Results...)
+	snapshot.GiftCodes.Results = append([]AuthResult(nil), Stats.GiftCodes.Results...)
+	snapshot.Types = map[mc.AccType]*TypeStats{}
+	for accType, typeStats := range Stats.Types {
+		copied := *typeStats
+		snapshot.Types[accType] = &copied
+	}
+	return snapshot
+}
+
+func newTypeStats() map[mc.AccType]*TypeStats {
+	return map[mc.AccType]*TypeStats{
+		mc.Ms:   {},
+		mc.MsPr: {},
+		mc.MsGp: {},
+	}
+}
+
+// resetPlanning clears what the previous snipe left from auth and planning, before a new one logs in
+func (st *StatsStore) resetPlanning() {
+	st.Warnings = nil
+	st.Auth = AuthProgress{}
+}
+
+// resetSends clears the request counters of the previous snipe, the clock calibration is kept
+func (st *StatsStore) resetSends() {
+	st.Total = 0
+	st.TooManyRequests = 0
+	st.Duplicate = 0
+	st.NotAllowed = 0
+	st.Success = 0
+	st.Cooldowns = 0
+	st.ProxyPenalties = 0
+	st.Unauthorized = 0
+	st.Quarantined = 0
+	st.Removed = 0
+	st.LateSends = 0
+	st.Unconfirmed = 0
+	st.StartTime = time.Now()
+	st.Types = newTypeStats()
+}
+
+// warn logs a planning problem and keeps it around for the stats
+func warn(message string, params ...interface{}) {
+	message = fmt.Sprintf(message, params...)
+	updateStats(func(st *StatsStore) {
+		st.Warnings = append(st.Warnings, message)
+		if len(st.Warnings) > maxWarnings {
+			st.Warnings = st.Warnings[len(st.Warnings)-maxWarnings:]
+		}
+	})
+	log.Log("warn", message)
+}
+
+// per-snipe settings, the zero value behaves like a plain snipe
+type Options struct {
+	CheckProxies     bool            // health check proxies after auth, dropping dead ones and preferring fast ones
+	ProxyCheckTarget string          // url the health check requests, proxy.DefaultCheckTarget if empty
+	MaxProxyLatency  time.Duration   // proxies slower than this are dropped by the health check, 0 for no limit
+	Prewarm          time.Duration   // open keep-alive connections on every proxy this long before the drop, 0 to skip
+	PrewarmConns     int             // connections opened per proxy when prewarming, defaults to 4
+	Calibrate        bool            // measure the local clock offset and shift the drop range by it
+	NTPServer        string          // calibrate against this sntp server instead of api Date headers
+	Scheduler        Scheduler       // how sends are spread over the drop range, EvenSpread if nil
+	LedgerPath       string          // where per-account request history is kept, DefaultLedgerPath if empty
+	Workers          int             // max concurrent requests, sized from the schedule and latency if 0
+	Precise          time.Duration   // sends this long after the window opens busy-wait instead of sleeping, 0 to always sleep
+	PostClaim        []PostClaimStep // run in order once the name is claimed
+	SkipVerify       bool            // end the claim on the first 200 without checking the name landed on the account
+	WatchInterval    time.Duration   // how often the name's availability is looked up, availability.DefaultInterval if 0
+	ProxyPolicy      ProxyPolicy     // rotate sends over every proxy or bind each account to one, RotateProxies if empty
+	Auth             AuthOptions     // concurrency, pacing and retries of the logins before the snipe
+	StartAfterAuth   bool            // move the drop range to open once the accounts are logged in, keeping its length
+}
+
+// ClaimWithinRange authenticates accounts, waits for the drop and snipes username within dropRange.
+// an error that stops the snipe is also sent out as an EventError.
+func ClaimWithinRange(username string, dropRange mc.DropRange, accounts []*mc.MCaccount, proxies []*proxy.Proxy, opts Options) error {
+	err := claimWithinRange(username, dropRange, accounts, proxies, opts)
+	if err != nil {
+		emit("err", Event{Type: EventError, Name: username}, "snipe of %v failed: %v", username, err)
+	}
+	return err
+}
 
-func ClaimWithinRange(username string, dropRange mc.DropRange, accounts []*mc.MCaccount, proxies []string) error {
+func claimWithinRange(username string, dropRange mc.DropRange, accounts []*mc.MCaccount, proxies []*proxy.Proxy, opts Options) error {
 
 	fmt.Print("\n")
 	log.Log("info", "sniping %s at %s", username, dropRange.Start.Format("02 Jan 06 15:04 MST"))
 
+	updateStats(func(st *StatsStore) { st.resetPlanning() })
+
 	for {
 		if time.Until(dropRange.Start) > authOffset {
 			color.Printf("\r[<fg=blue>*</>] authing in %v    ", time.Until(dropRange.Start.Add(-time.Hour*8)).Round(time.Second))
@@ -41,69 +174,58 @@ func ClaimWithinRange(username string, dropRange mc.DropRange, accounts []*mc.MC
 		}
 	}
 
-	usableAccounts := []*mc.MCaccount{}
-
-	for i, account := range accounts {
-
-		if account.Bearer != "" {
-			usableAccounts = append(usableAccounts, account)
-			continue
-		}
-
-		if i != 0 {
-			time.Sleep(time.Second * 21)
-		}
-
-		authErr := account.MicrosoftAuthenticate("")
-		if authErr != nil {
-			log.Log("err", "failed to authenticate %v: %v", account.Email, authErr)
-			time.Sleep(time.Second * 21)
-			continue
-		} else {
-			log.Log("success", "authenticated %s", account.Email)
-		}
-
-		time.Sleep(time.Millisecond * 500)
-		if account.Type == mc.MsGp {
-			licenseErr := account.License()
-			if licenseErr != nil {
-				log.Log("err", "failed to license %v: %v", account.Email, licenseErr)
-				continue
+	// bound accounts log in through their proxy, so dead ones have to be dropped before that
+	if opts.ProxyPolicy == BindProxies {
+		if opts.CheckProxies && len(proxies) > 0 {
+			checked, _, err := checkProxies(proxies, opts.ProxyCheckTarget, opts.MaxProxyLatency)
+			if err != nil {
+				return err
 			}
-			usableAccounts = append(usableAccounts, account)
+			proxies = checked
 		}
+		bindProxies(accounts, proxies)
+	}
 
-		if account.Type == mc.Ms {
-			_, checkErr := account.NameChangeInfo()
-			if checkErr != nil {
-				log.Log("err", "failed to confirm name change for %v: %v", account.Email, checkErr)
-				continue
-			}
-			usableAccounts = append(usableAccounts, account)
-			continue
+	usableAccounts := []*mc.MCaccount{}
+	for _, result := range authenticate(username, accounts, opts.Auth) {
+		if result.Err == nil {
+			usableAccounts = append(usableAccounts, result.Account)
 		}
+	}
 
-		if account.Type == mc.MsPr {
-			_, checkErr := account.HasGcApplied()
-
-			if checkErr != nil {
-				log.Log("err", "failed to confirm gift code claim for %v: %v", account.Email, checkErr)
-				continue
-			}
+	if len(usableAccounts) == 0 {
+		return errors.New("no accounts successfully authenticated")
+	} else {
+		emit("success", Event{Type: EventAuthed, Name: username, Count: len(usableAccounts)}, "authenticated %d account(s)\n", len(usableAccounts))
+	}
 
-			usableAccounts = append(usableAccounts, account)
+	var rotation []*proxy.Proxy
+	if opts.CheckProxies && len(proxies) > 0 && opts.ProxyPolicy != BindProxies {
+		checked, weighted, err := checkProxies(proxies, opts.ProxyCheckTarget, opts.MaxProxyLatency)
+		if err != nil {
+			return err
 		}
+		proxies, rotation = checked, weighted
+	}
 
+	// a snipe that starts right away shouldn't spend its window logging in
+	if opts.StartAfterAuth && !dropRange.Start.IsZero() {
+		length := dropRange.End.Sub(dropRange.Start)
+		dropRange.Start = time.Now()
+		if !dropRange.End.IsZero() {
+			dropRange.End = dropRange.Start.Add(length)
+		}
+		log.Log("info", "logins done, sniping %s until %s", username, dropRange.End.Format("15:04:05"))
 	}
 
-	if len(usableAccounts) == 0 {
-		return errors.New("no accounts successfully authenticated")
-	} else {
-		log.Log("success", "authenticated %d account(s)\n", len(usableAccounts))
+	// the claim has to be running before the prewarm window opens
+	leadTime := time.Second * 20
+	if opts.Prewarm+time.Second*5 > leadTime {
+		leadTime = opts.Prewarm + time.Second*5
 	}
 
 	for {
-		if time.Until(dropRange.Start) > time.Second*20 {
+		if time.Until(dropRange.Start) > leadTime {
 			color.Printf("\r[<fg=blue>*</>] sniping in %v    ", time.Until(dropRange.Start).Round(time.Second))
 			time.Sleep(time.Second * 1)
 		} else {
@@ -112,15 +234,22 @@ func ClaimWithinRange(username string, dropRange mc.DropRange, accounts []*mc.MC
 		}
 	}
 
+	if opts.Calibrate {
+		dropRange = calibrateClock(dropRange, opts.NTPServer)
+	}
+
 	snipe := &Claim{
 		Username:  username,
 		Accounts:  usableAccounts,
 		DropRange: dropRange,
 		Running:   true,
 		Proxies:   proxies,
+		Options:   opts,
+		rotation:  rotation,
 	}
 
 	snipe.runClaim()
+	snipe.followUp()
 
 	return nil
 }
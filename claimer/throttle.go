@@ -0,0 +1,112 @@
+package claimer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Kqzz/MCsniperGO/pkg/mc"
+	"github.com/Kqzz/MCsniperGO/pkg/proxy"
+)
+
+const (
+	proxyStrikeLimit = 3 // consecutive 429s before a proxy is benched
+	proxyPenaltyBase = time.Second * 30
+	proxyPenaltyMax  = time.Minute * 5
+)
+
+// throttle reacts to 429s: accounts rest until their short window resets, proxies that keep
+// getting limited are benched with an exponential backoff.
+type throttle struct {
+	mu           sync.Mutex
+	accountUntil map[*mc.MCaccount]time.Time
+	proxyStrikes map[string]int
+	proxyUntil   map[string]time.Time
+}
+
+func newThrottle() *throttle {
+	return &throttle{
+		accountUntil: map[*mc.MCaccount]time.Time{},
+		proxyStrikes: map[string]int{},
+		proxyUntil:   map[string]time.Time{},
+	}
+}
+
+func (t *throttle) accountReady(acc *mc.MCaccount, at time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return !at.Before(t.accountUntil[acc])
+}
+
+func (t *throttle) proxyReady(p *proxy.Proxy, at time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return !at.Before(t.proxyUntil[p.String()])
+}
+
+// ready counts the accounts that aren't cooling down at the given time
+func (t *throttle) ready(accounts []*mc.MCaccount, at time.Time) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ready := 0
+	for _, acc := range accounts {
+		if !at.Before(t.accountUntil[acc]) {
+			ready++
+		}
+	}
+	return ready
+}
+
+// tooManyRequests benches the account for the rest of its short window and strikes the proxy.
+// the events go out once t.mu is released, so subscribers can't stall the other workers.
+func (t *throttle) tooManyRequests(claim ClaimAttempt, ledger *Ledger, at time.Time) {
+	type pending struct {
+		level   string
+		event   Event
+		message string
+	}
+	var events []pending
+
+	t.mu.Lock()
+
+	event := Event{Name: claim.Name, AccType: claim.AccType, AccNum: claim.AccNum, Proxy: claim.Proxy.Redacted()}
+
+	if acc := claim.Account; acc != nil && !at.Before(t.accountUntil[acc]) {
+		until := ledger.WindowReset(acc, at)
+		t.accountUntil[acc] = until
+		updateStats(func(st *StatsStore) { st.Cooldowns++ })
+
+		event.Type = EventCooldown
+		events = append(events, pending{"warn", event, fmt.Sprintf("account #%d (%v) got 429, cooling down for %v", claim.AccNum, claim.AccType, until.Sub(at).Round(time.Second))})
+	}
+
+	key := claim.Proxy.String()
+	t.proxyStrikes[key]++
+	strikes := t.proxyStrikes[key]
+
+	if strikes >= proxyStrikeLimit && !at.Before(t.proxyUntil[key]) {
+		penalty := proxyPenaltyBase << (strikes - proxyStrikeLimit)
+		if penalty > proxyPenaltyMax || penalty <= 0 {
+			penalty = proxyPenaltyMax
+		}
+		t.proxyUntil[key] = at.Add(penalty)
+		updateStats(func(st *StatsStore) { st.ProxyPenalties++ })
+
+		event.Type = EventProxyPenalty
+		events = append(events, pending{"warn", event, fmt.Sprintf("proxy %v got %d 429s in a row, benched for %v", event.Proxy, strikes, penalty)})
+	}
+
+	t.mu.Unlock()
+
+	for _, e := range events {
+		emit(e.level, e.event, "%s", e.message)
+	}
+}
+
+// answered clears the strikes of a proxy that got a non-429 response
+func (t *throttle) answered(p *proxy.Proxy) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.proxyStrikes, p.String())
+}
@@ -0,0 +1,196 @@
+package claimer
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Kqzz/MCsniperGO/log"
+)
+
+// how long before a precise send the generator stops sleeping and busy-waits, sleeps can overshoot by about this much
+const spinMargin = time.Millisecond * 2
+
+var (
+	driftBounds     = []time.Duration{time.Microsecond * 100, time.Microsecond * 500, time.Millisecond, time.Millisecond * 5, time.Millisecond * 10, time.Millisecond * 50, time.Millisecond * 100, time.Millisecond * 500}
+	roundTripBounds = []time.Duration{time.Millisecond * 25, time.Millisecond * 50, time.Millisecond * 100, time.Millisecond * 200, time.Millisecond * 400, time.Millisecond * 800, time.Second * 2}
+)
+
+// Timing is when a single attempt was planned, picked up by the generator, put on the wire and answered
+type Timing struct {
+	Scheduled  time.Time
+	Dispatched time.Time
+	Sent       time.Time
+	Answered   time.Time // zero if the request failed
+}
+
+// Drift is how late the request went out compared to the plan
+func (t Timing) Drift() time.Duration {
+	return t.Sent.Sub(t.Scheduled)
+}
+
+func (t Timing) RoundTrip() time.Duration {
+	if t.Answered.IsZero() {
+		return 0
+	}
+	return t.Answered.Sub(t.Sent)
+}
+
+type Bucket struct {
+	Upper time.Duration // exclusive, 0 for the last bucket that holds everything above the others
+	Count int
+}
+
+// Histogram counts durations into fixed buckets
+type Histogram struct {
+	mu     sync.Mutex
+	bounds []time.Duration
+	counts []int
+	n      int
+	sum    time.Duration
+	max    time.Duration
+}
+
+func NewHistogram(bounds []time.Duration) *Histogram {
+	return &Histogram{bounds: bounds, counts: make([]int, len(bounds)+1)}
+}
+
+func (h *Histogram) Add(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if d < 0 {
+		d = 0
+	}
+
+	i := 0
+	for i < len(h.bounds) && d >= h.bounds[i] {
+		i++
+	}
+	h.counts[i]++
+
+	h.n++
+	h.sum += d
+	if d > h.max {
+		h.max = d
+	}
+}
+
+func (h *Histogram) Buckets() []Bucket {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	buckets := make([]Bucket, len(h.counts))
+	for i, count := range h.counts {
+		buckets[i].Count = count
+		if i < len(h.bounds) {
+			buckets[i].Upper = h.bounds[i]
+		}
+	}
+	return buckets
+}
+
+// Summary returns the number of samples, their mean and the largest one
+func (h *Histogram) Summary() (n int, mean time.Duration, max time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.n == 0 {
+		return 0, 0, 0
+	}
+	return h.n, h.sum / time.Duration(h.n), h.max
+}
+
+// log prints one line per non-empty bucket with a bar scaled to the fullest one
+func (h *Histogram) log(title string) {
+	n, mean, max := h.Summary()
+	if n == 0 {
+		return
+	}
+	log.Log("info", "%v over %d requests: mean %v, max %v", title, n, mean.Round(time.Microsecond), max.Round(time.Microsecond))
+
+	buckets := h.Buckets()
+	most := 0
+	for _, b := range buckets {
+		if b.Count > most {
+			most = b.Count
+		}
+	}
+
+	lower := time.Duration(0)
+	for _, b := range buckets {
+		label := fmt.Sprintf(">=%v", lower)
+		if b.Upper > 0 {
+			label = fmt.Sprintf("<%v", b.Upper)
+			lower = b.Upper
+		}
+		if b.Count == 0 {
+			continue
+		}
+		log.Log("info", "  %-8v %5d %v", label, b.Count, strings.Repeat("#", (b.Count*30+most-1)/most))
+	}
+}
+
+// recent attempts kept per claim, the histograms still count every one
+const keptTimings = 1024
+
+// timings keeps the histograms of a claim and its latest attempts in a ring
+type timings struct {
+	mu        sync.Mutex
+	recent    []Timing
+	next      int // where the next attempt goes once recent is full
+	drift     *Histogram
+	roundTrip *Histogram
+}
+
+func newTimings() *timings {
+	return &timings{
+		drift:     NewHistogram(driftBounds),
+		roundTrip: NewHistogram(roundTripBounds),
+	}
+}
+
+func (t *timings) record(timing Timing) {
+	t.mu.Lock()
+	if len(t.recent) < keptTimings {
+		t.recent = append(t.recent, timing)
+	} else {
+		t.recent[t.next] = timing
+		t.next = (t.next + 1) % keptTimings
+	}
+	t.mu.Unlock()
+
+	t.drift.Add(timing.Drift())
+	if !timing.Answered.IsZero() {
+		t.roundTrip.Add(timing.RoundTrip())
+	}
+}
+
+// Timings returns the latest attempts, up to 1024, in the order they finished
+func (s *Claim) Timings() []Timing {
+	if s.timings == nil {
+		return nil
+	}
+
+	s.timings.mu.Lock()
+	defer s.timings.mu.Unlock()
+	return append(append([]Timing{}, s.timings.recent[s.timings.next:]...), s.timings.recent[:s.timings.next]...)
+}
+
+func (s *Claim) logTimings() {
+	s.timings.drift.log("send drift")
+	s.timings.roundTrip.log("round trip")
+}
+
+// waitUntil sleeps until t, busy-waiting the last spin of it for a send that has to go out on time
+func waitUntil(t time.Time, spin time.Duration) {
+	if spin <= 0 {
+		time.Sleep(time.Until(t))
+		return
+	}
+
+	time.Sleep(time.Until(t) - spin)
+	for time.Now().Before(t) {
+	}
+}
@@ -0,0 +1,173 @@
+package claimer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Kqzz/MCsniperGO/log"
+	"github.com/Kqzz/MCsniperGO/pkg/availability"
+	"github.com/Kqzz/MCsniperGO/pkg/mc"
+)
+
+const (
+	EventUnconfirmed EventType = "unconfirmed" // a 200 didn't hold up when checked, the snipe carries on
+
+	verifyTries      = 3
+	verifyRetryDelay = time.Second // lookups can lag behind a fresh claim
+)
+
+// claimMismatch means the name is provably not on the claiming account
+type claimMismatch struct {
+	reason string
+}
+
+func (m claimMismatch) Error() string {
+	return m.reason
+}
+
+// verifyClaim checks name is held by acc, matching the uuid a public lookup gives for it to acc's own profile.
+// a claimMismatch means the claim didn't happen, any other error that it couldn't be checked.
+func verifyClaim(name string, acc *mc.MCaccount) error {
+	if acc == nil {
+		return errors.New("no account to check")
+	}
+
+	var lastErr error
+	for try := 0; try < verifyTries; try++ {
+		if try > 0 {
+			time.Sleep(verifyRetryDelay)
+		}
+
+		if err := acc.LoadAccountInfo(); err != nil {
+			lastErr = fmt.Errorf("failed to load account profile: %v", err)
+			continue
+		}
+		if !strings.EqualFold(acc.Username, name) {
+			return claimMismatch{fmt.Sprintf("the account's profile is named %q", acc.Username)}
+		}
+
+		profile, status, err := mc.UsernameToUuid(name)
+		if err != nil && status != 404 {
+			lastErr = fmt.Errorf("failed to look up %v: %v", name, err)
+			continue
+		}
+		if status == 404 {
+			lastErr = fmt.Errorf("%v doesn't show up in lookups yet", name)
+			continue
+		}
+		if status != 200 {
+			lastErr = fmt.Errorf("lookup of %v answered %v", name, status)
+			continue
+		}
+
+		if !sameUUID(profile.ID, acc.UUID) {
+			return claimMismatch{fmt.Sprintf("%v belongs to %v, not the claiming account %v", name, profile.ID, acc.UUID)}
+		}
+		return nil
+	}
+	return lastErr
+}
+
+func sameUUID(a, b string) bool {
+	return strings.EqualFold(strings.ReplaceAll(a, "-", ""), strings.ReplaceAll(b, "-", ""))
+}
+
+// pendingClaims counts the 200s being confirmed and holds back the name being taken meanwhile
+type pendingClaims struct {
+	mu    sync.Mutex
+	count int
+	taken *availability.Change
+}
+
+func (p *pendingClaims) add() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.count++
+}
+
+// hold keeps change for later if a 200 is being confirmed
+func (p *pendingClaims) hold(change availability.Change) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.count == 0 {
+		return false
+	}
+	p.taken = &change
+	return true
+}
+
+// done returns the held change once the last 200 is confirmed
+func (p *pendingClaims) done() (availability.Change, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.count--
+	if p.count > 0 || p.taken == nil {
+		return availability.Change{}, false
+	}
+	change := *p.taken
+	p.taken = nil
+	return change, true
+}
+
+// succeeded checks a 200 off the worker, which goes back to sending while the lookups run
+func (s *Claim) succeeded(claim ClaimAttempt, at time.Time) {
+	s.verifies.Add(1)
+	s.pending.add()
+	go func() {
+		defer s.verifies.Done()
+		s.confirm(claim, at)
+		if change, ok := s.pending.done(); ok {
+			s.availabilityChanged(change)
+		}
+	}()
+}
+
+// confirm checks a 200 before ending the claim on it. when the check disagrees the claim keeps
+// going, so it resumes on its own as long as the drop range isn't over.
+func (s *Claim) confirm(claim ClaimAttempt, at time.Time) {
+	s.verifying.Lock()
+	defer s.verifying.Unlock()
+
+	if s.claimed() != nil {
+		return
+	}
+
+	event := Event{Name: claim.Name, AccType: claim.AccType, AccNum: claim.AccNum, Proxy: claim.Proxy.Redacted(), Account: RedactAccount(claim.Account)}
+
+	verified := false
+	if !s.Options.SkipVerify {
+		log.Log("info", "got 200 for %v on %v #%d, verifying", claim.Name, claim.AccType, claim.AccNum)
+
+		err := verifyClaim(claim.Name, claim.Account)
+		if mismatch, ok := err.(claimMismatch); ok {
+			updateStats(func(st *StatsStore) { st.Unconfirmed++ })
+			event.Type = EventUnconfirmed
+
+			if !s.DropRange.End.IsZero() && !time.Now().Before(s.DropRange.End) {
+				emit("err", event, "200 for %v on %v #%d didn't hold up (%v), drop range is over", claim.Name, claim.AccType, claim.AccNum, mismatch)
+				return
+			}
+			emit("warn", event, "200 for %v on %v #%d didn't hold up (%v), resuming snipe", claim.Name, claim.AccType, claim.AccNum, mismatch)
+			return
+		}
+
+		if err != nil {
+			log.Log("warn", "couldn't verify claim of %v, trusting the 200: %v", claim.Name, err)
+		}
+		verified = err == nil
+	}
+
+	event.Type = EventClaimed
+	emit("success", event, "Claimed %v on %v acc %v", claim.Name, claim.AccType, event.Account)
+	log.Log("success", "Join https://discord.gg/2BZseKW for more!")
+
+	updateStats(func(st *StatsStore) {
+		st.Success++
+		st.Types[claim.AccType].Success++
+	})
+	s.won(claim, at, verified)
+	s.Running = false
+}
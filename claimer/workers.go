@@ -0,0 +1,133 @@
+package claimer
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/Kqzz/MCsniperGO/log"
+)
+
+const (
+	maxAutoWorkers = 100
+	defaultLatency = time.Second // assumed round trip when nothing was measured
+)
+
+// workerPool starts workers as sends need them, up to max. a send that finds every worker busy
+// and the pool full waits for one to free up and is counted as late.
+type workerPool struct {
+	work    chan ClaimAttempt
+	kill    chan bool
+	clients *clientPool
+
+	mu      sync.Mutex
+	max     int
+	running int
+	wg      sync.WaitGroup
+}
+
+func newWorkerPool(max int, clients *clientPool, kill chan bool) *workerPool {
+	if max < 1 {
+		max = 1
+	}
+	return &workerPool{
+		work:    make(chan ClaimAttempt),
+		kill:    kill,
+		clients: clients,
+		max:     max,
+	}
+}
+
+func (w *workerPool) run(claim ClaimAttempt) {
+	defer w.wg.Done()
+	for {
+		claimName(claim, w.clients)
+
+		select {
+		case claim = <-w.work:
+		case <-w.kill:
+			return
+		}
+	}
+}
+
+// submit hands claim to an idle worker, or a new one if the pool has room. false once the claim is killed.
+func (w *workerPool) submit(claim ClaimAttempt) bool {
+	select {
+	case w.work <- claim:
+		return true
+	default:
+	}
+
+	w.mu.Lock()
+	if w.running < w.max {
+		w.running++
+		w.wg.Add(1)
+		w.mu.Unlock()
+		go w.run(claim)
+		return true
+	}
+	w.mu.Unlock()
+	updateStats(func(st *StatsStore) { st.LateSends++ })
+
+	select {
+	case w.work <- claim:
+		return true
+	case <-w.kill:
+		return false
+	}
+}
+
+// wait blocks until every worker has finished its last request, or timeout passes
+func (w *workerPool) wait(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		log.Log("warn", "workers still busy after %v, not waiting for them", timeout)
+	}
+}
+
+// peakRate is the most sends a plan puts into any one second
+func peakRate(plan Plan) int {
+	offsets := plan.Offsets
+	if plan.Repeat > 0 {
+		// replay the plan long enough to see a full second of it
+		offsets = []time.Duration{}
+		for base := time.Duration(0); base < time.Second*2; base += plan.Repeat {
+			for _, off := range plan.Offsets {
+				offsets = append(offsets, base+off)
+			}
+		}
+	}
+
+	peak := 0
+	first := 0
+	for last := range offsets {
+		for offsets[last]-offsets[first] >= time.Second {
+			first++
+		}
+		if n := last - first + 1; n > peak {
+			peak = n
+		}
+	}
+	return peak
+}
+
+// autoWorkers sizes the pool so the peak rate can all be in flight at the given latency, with headroom for jitter
+func autoWorkers(rate int, latency time.Duration) int {
+	if latency <= 0 {
+		latency = defaultLatency
+	}
+
+	n := int(math.Ceil(float64(rate)*latency.Seconds()*2)) + 1
+	if n > maxAutoWorkers {
+		n = maxAutoWorkers
+	}
+	return n
+}
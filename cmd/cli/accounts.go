@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/Kqzz/MCsniperGO/log"
+	"github.com/Kqzz/MCsniperGO/pkg/mc"
+	"github.com/Kqzz/MCsniperGO/pkg/parser"
+)
+
+// txt file each account type is kept in
+var accountFiles = []struct {
+	path    string
+	accType mc.AccType
+}{
+	{"gc.txt", mc.MsPr},
+	{"gp.txt", mc.MsGp},
+	{"ms.txt", mc.Ms},
+}
+
+// importAccounts merges gc.txt, gp.txt and ms.txt into the accounts file, accounts already in it are kept as they are
+func importAccounts() {
+	existing, err := parser.LoadAccountsFile(accountsPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Log("err", "failed to load %s: %v", accountsPath, err)
+		return
+	}
+
+	imported := [][]parser.AccountRecord{}
+	for _, f := range accountFiles {
+		lines, err := parser.ReadLines(f.path)
+		if err != nil {
+			continue
+		}
+
+		records, errs := parser.ParseRecords(lines, f.accType)
+		for _, er := range errs {
+			log.Log("err", "%s: %v", f.path, er)
+		}
+		imported = append(imported, records)
+	}
+
+	merged, added := parser.ImportTxt(existing, imported...)
+	err = parser.SaveAccountsFile(accountsPath, merged)
+	if err != nil {
+		log.Log("err", "failed to write %s: %v", accountsPath, err)
+		return
+	}
+	log.Log("success", "imported %d new account(s), %s has %d", added, accountsPath, len(merged))
+}
+
+// exportAccounts writes the accounts file back out to gc.txt, gp.txt and ms.txt, existing files are kept as .bak
+func exportAccounts() {
+	records, err := parser.LoadAccountsFile(accountsPath)
+	if err != nil {
+		log.Log("err", "failed to load %s: %v", accountsPath, err)
+		return
+	}
+
+	lines := parser.ExportTxt(records)
+	for _, f := range accountFiles {
+		if len(lines[f.accType]) == 0 {
+			continue
+		}
+
+		if _, err := os.Stat(f.path); err == nil {
+			if err := os.Rename(f.path, f.path+".bak"); err != nil {
+				log.Log("err", "failed to back up %s: %v", f.path, err)
+				continue
+			}
+		}
+
+		err := os.WriteFile(f.path, []byte(strings.Join(lines[f.accType], "\n")+"\n"), 0644)
+		if err != nil {
+			log.Log("err", "failed to write %s: %v", f.path, err)
+			continue
+		}
+		log.Log("success", "wrote %d account(s) to %s", len(lines[f.accType]), f.path)
+	}
+}
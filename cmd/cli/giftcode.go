@@ -0,0 +1,39 @@
+package main
+
+import (
+	"github.com/Kqzz/MCsniperGO/claimer"
+	"github.com/Kqzz/MCsniperGO/log"
+	"github.com/Kqzz/MCsniperGO/pkg/parser"
+)
+
+// redeemGiftCodes redeems the gift code of every email:password:giftcode[:proxy] line in path,
+// then adds the accounts that are ready to the accounts file, or gc.txt if there isn't one
+func redeemGiftCodes(path string, auth claimer.AuthOptions) {
+	lines, err := parser.ReadLines(path)
+	if err != nil {
+		log.Log("err", "failed to read %s: %v", path, err)
+		return
+	}
+
+	codes, errs := parser.ParseGiftCodes(lines)
+	for _, er := range errs {
+		log.Log("err", "%s: %v", path, er)
+	}
+	if len(codes) == 0 {
+		log.Log("err", "no gift codes to redeem in %s", path)
+		return
+	}
+
+	log.Log("info", "redeeming %d gift code(s)", len(codes))
+	ready, errs := claimer.RedeemGiftCodeLines(codes, auth)
+	for _, er := range errs {
+		log.Log("err", "%v", er)
+	}
+
+	added, err := parser.AddAccounts(accountsPath, "gc.txt", ready)
+	if err != nil {
+		log.Log("err", "failed to save the redeemed accounts: %v", err)
+		return
+	}
+	log.Log("success", "%d/%d account(s) ready to snipe with, %d added", len(ready), len(codes), added)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Kqzz/MCsniperGO/log"
+	"github.com/Kqzz/MCsniperGO/pkg/mc"
+	"github.com/Kqzz/MCsniperGO/pkg/parser"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	lookupBackoff    = time.Second * 10
+	lookupBackoffMax = time.Minute * 2
+)
+
+// readNames reads one name per line, skipping blanks, # comments and repeats
+func readNames(path string) ([]string, error) {
+	lines, err := parser.ReadLines(path)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	seen := map[string]bool{}
+	for _, line := range lines {
+		name := strings.TrimSpace(line)
+		if name == "" || strings.HasPrefix(name, "#") || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// lookupNames resolves every name in namesPath with the batch endpoint and writes name,uuid,available,error rows to outPath.
+// batches rotate over the proxies in proxies.txt, a ratelimited proxy hands its batch to the next one.
+// names of a batch that failed for another reason are written as unknown with the error.
+func lookupNames(namesPath string, outPath string) {
+	names, err := readNames(namesPath)
+	if err != nil {
+		log.Log("err", "failed to read names: %v", err)
+		return
+	}
+	if len(names) == 0 {
+		log.Log("err", "no names in %s", namesPath)
+		return
+	}
+
+	lookups := []*mc.BatchLookup{mc.NewBatchLookup(nil)}
+
+	proxies, _ := getProxies("proxies.txt")
+	if len(proxies) > 0 {
+		lookups = []*mc.BatchLookup{}
+		for _, p := range proxies {
+			lookups = append(lookups, mc.NewBatchLookup(&fasthttp.Client{Dial: p.Dialer()}))
+		}
+	}
+
+	log.Log("info", "looking up %d names in batches of %d over %d connection(s)", len(names), mc.ProfilesBatchSize, len(lookups))
+
+	results := []mc.NameLookup{}
+	next := 0
+	limited := 0
+	backoff := lookupBackoff
+
+	for start := 0; start < len(names); {
+		end := start + mc.ProfilesBatchSize
+		if end > len(names) {
+			end = len(names)
+		}
+
+		batch, err := lookups[next%len(lookups)].Batch(names[start:end])
+		next++
+
+		if errors.Is(err, mc.ErrLookupRatelimited) {
+			limited++
+			if limited < len(lookups) {
+				continue
+			}
+
+			log.Log("warn", "every connection is ratelimited, waiting %v", backoff)
+			time.Sleep(backoff)
+			limited = 0
+			if backoff *= 2; backoff > lookupBackoffMax {
+				backoff = lookupBackoffMax
+			}
+			continue
+		}
+		if err != nil {
+			log.Log("err", "failed to look up %s: %v", strings.Join(names[start:end], ", "), err)
+			for _, name := range names[start:end] {
+				results = append(results, mc.NameLookup{Name: name, Err: err})
+			}
+			start = end
+			continue
+		}
+
+		limited = 0
+		backoff = lookupBackoff
+		results = append(results, batch...)
+		start = end
+	}
+
+	err = writeLookups(outPath, results)
+	if err != nil {
+		log.Log("err", "failed to write %s: %v", outPath, err)
+		return
+	}
+
+	available, unknown := 0, 0
+	for _, r := range results {
+		if r.Err != nil {
+			unknown++
+		} else if r.Available {
+			available++
+		}
+	}
+	if unknown > 0 {
+		log.Log("warn", "%d name(s) couldn't be looked up, they're marked unknown", unknown)
+	}
+	log.Log("success", "%d/%d names available, wrote %s", available, len(results), outPath)
+}
+
+func writeLookups(path string, results []mc.NameLookup) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	w.Write([]string{"name", "uuid", "available", "error"})
+	for _, r := range results {
+		if r.Err != nil {
+			w.Write([]string{r.Name, "", "unknown", r.Err.Error()})
+			continue
+		}
+		available := "false"
+		if r.Available {
+			available = "true"
+		}
+		w.Write([]string{r.Name, r.UUID, available, ""})
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -10,7 +10,12 @@ import (
 
 	"github.com/Kqzz/MCsniperGO/claimer"
 	"github.com/Kqzz/MCsniperGO/log"
+	"github.com/Kqzz/MCsniperGO/pkg/mc"
+	"github.com/Kqzz/MCsniperGO/pkg/notify"
 	"github.com/Kqzz/MCsniperGO/pkg/parser"
+	"github.com/Kqzz/MCsniperGO/pkg/proxy"
+	"github.com/Kqzz/MCsniperGO/pkg/selector"
+	"github.com/Kqzz/MCsniperGO/pkg/watchlist"
 	"github.com/Kqzz/MCsniperGO/pkg/webserver"
 )
 
@@ -21,12 +26,88 @@ options:
 	--disable-bar           disables the status bar (CLI mode)
 	--web                   run in web server mode instead of CLI
 	--port <str>            port for web server (default: ":8080")
+	--check-proxies         check proxies.txt, print their latency and exit
+	--lookup <path>         look up every name in the file, write a csv of name,uuid,available,error and exit
+	--lookup-out <path>     csv written by --lookup (default: "lookup.csv")
+	--drop-dead-proxies     health check proxies before the drop and skip dead ones (CLI mode)
+	--proxy-target <url>    url used for proxy checks (default: "https://api.minecraftservices.com/")
+	--max-proxy-latency <ms> with --drop-dead-proxies, also skip proxies slower than this (default: 0, keep every alive one)
+	--prewarm <seconds>     open connections this many seconds before the drop (CLI mode, default: 0)
+	--calibrate             measure local clock offset before the drop and adjust for it (CLI mode)
+	--ntp <host>            calibrate against an sntp server instead of api Date headers
+	--schedule <spec>       request schedule: even, burst[:gap], decay[:power], fixed:<ms>, file:<path> (default: even)
+	--ledger <path>         file that tracks per-account request budgets across snipes (default: "budget.json")
+	--proxy-policy <str>    rotate sends over every proxy, or bind each account to one for auth, checks and sends (default: rotate)
+	--auth-concurrency <n>  accounts logging in at once (default: 4)
+	--auth-pacing <sec>     seconds between logins through the same proxy (default: 21)
+	--login-timeout <sec>   timeout of each request while logging in (default: 30)
+	--ca-bundle <path>      pem certificates to trust while logging in, on top of the system ones
+	--insecure-skip-verify  don't verify tls certificates while logging in. debugging only, this exposes your passwords
+	--auth-retries <n>      retries of a login that failed for a reason other than the account itself (default: 2)
+	--workers <n>           max concurrent requests, 0 sizes it from the schedule (default: 0)
+	--precise <ms>          busy-wait sends in the first <ms> of the window for tighter timing (default: 0)
+	--skin <url|path>       skin to apply to the account once the name is claimed
+	--skin-variant <str>    classic or slim (default: "classic")
+	--no-verify             end the snipe on the first 200 without checking the name landed on the account
+	--history <path>        file claimed names are recorded in, empty to disable (default: "history.jsonl")
+	--follow-up <str>       username to snipe next with the accounts that didn't claim
+	--follow-up-range <str> droptime range (start-end/infinite) for --follow-up
+	--webhooks <path>       webhook config shared with the web server (default: "webhooks.json")
+	--watchlist <cmd>       manage the watchlist and exit: add <names...>, remove <names...>, list, or run to watch it
+	--watchlist-file <path> watchlist shared with the web server (default: "watchlist.json")
+	--priority <n>          priority of names added with --watchlist add, higher is sniped first (default: 0)
+	--notes <str>           notes for names added with --watchlist add
+	--action <str>          alert or snipe once a name added with --watchlist add is available (default: "alert")
+	--select <expr>         only use matching accounts, e.g. "group:team-a and not tag:reserve" (default: every account)
+	--watch-interval <sec>  seconds between availability checks of each watched or sniped name (default: 60)
+	--accounts <path>       accounts file, used instead of gc.txt, gp.txt and ms.txt when it exists (default: "accounts.json")
+	--accounts-import       merge gc.txt, gp.txt and ms.txt into the accounts file and exit
+	--redeem <path>         redeem the gift code of every email:password:giftcode[:proxy] line, add the accounts as GC and exit
+	--accounts-export       write the accounts file out to gc.txt, gp.txt and ms.txt (old ones kept as .bak) and exit
 `
 
 var (
-	disableBar bool
-	webMode    bool
-	webPort    string
+	disableBar      bool
+	webMode         bool
+	webPort         string
+	checkProxyMode  bool
+	lookupPath      string
+	lookupOut       string
+	dropDeadProxies bool
+	proxyTarget     string
+	maxProxyLatency int
+	prewarmSeconds  int
+	calibrate       bool
+	ntpServer       string
+	scheduleSpec    string
+	ledgerPath      string
+	proxyPolicy     string
+	authConcurrency int
+	authPacing      int
+	authRetries     int
+	loginTimeout    int
+	caBundle        string
+	insecureTLS     bool
+	workers         int
+	preciseMs       int
+	skin            string
+	skinVariant     string
+	noVerify        bool
+	historyPath     string
+	followUp        string
+	followUpRange   string
+	webhooksPath    string
+	watchlistCmd    string
+	watchlistPath   string
+	watchPriority   int
+	watchNotes      string
+	watchAction     string
+	selectExpr      string
+	watchInterval   int
+	accountsPath    string
+	accountsImport  bool
+	accountsExport  bool
+	redeemPath      string
 )
 
 func init() {
@@ -59,9 +140,10 @@ func statusBar(startTime time.Time) {
 
 	elapsed := time.Since(startTime).Seconds()
 
-	requestsPerSecond := float64(claimer.Stats.Total) / elapsed
+	stats := claimer.StatsSnapshot()
+	requestsPerSecond := float64(stats.Total) / elapsed
 
-	fmt.Printf("[RPS: %.2f | DUPLICATE: %d | NOT_ALLOWED: %d | TOO_MANY_REQUESTS: %d]     ", requestsPerSecond, claimer.Stats.Duplicate, claimer.Stats.NotAllowed, claimer.Stats.TooManyRequests)
+	fmt.Printf("[RPS: %.2f | DUPLICATE: %d | NOT_ALLOWED: %d | TOO_MANY_REQUESTS: %d | COOLDOWNS: %d | LATE: %d]     ", requestsPerSecond, stats.Duplicate, stats.NotAllowed, stats.TooManyRequests, stats.Cooldowns, stats.LateSends)
 	fmt.Print("\x1B8") // Restore the cursor position util new size is calculated
 }
 
@@ -72,6 +154,44 @@ func runCli() {
 	flag.BoolVar(&disableBar, "disable-bar", false, "disables status bar")
 	flag.BoolVar(&webMode, "web", false, "run in web server mode")
 	flag.StringVar(&webPort, "port", ":8080", "port for web server")
+	flag.BoolVar(&checkProxyMode, "check-proxies", false, "check proxies and exit")
+	flag.StringVar(&lookupPath, "lookup", "", "names to look up")
+	flag.StringVar(&lookupOut, "lookup-out", "lookup.csv", "lookup csv output")
+	flag.BoolVar(&dropDeadProxies, "drop-dead-proxies", false, "health check proxies before the drop")
+	flag.StringVar(&proxyTarget, "proxy-target", proxy.DefaultCheckTarget, "url used for proxy checks")
+	flag.IntVar(&maxProxyLatency, "max-proxy-latency", 0, "skip proxies slower than this many ms")
+	flag.IntVar(&prewarmSeconds, "prewarm", 0, "seconds before the drop to open connections")
+	flag.BoolVar(&calibrate, "calibrate", false, "calibrate clock offset before the drop")
+	flag.StringVar(&ntpServer, "ntp", "", "sntp server used for clock calibration")
+	flag.StringVar(&scheduleSpec, "schedule", "even", "request schedule")
+	flag.StringVar(&ledgerPath, "ledger", claimer.DefaultLedgerPath, "request budget ledger")
+	flag.StringVar(&proxyPolicy, "proxy-policy", string(claimer.RotateProxies), "rotate or bind")
+	flag.IntVar(&authConcurrency, "auth-concurrency", 4, "concurrent logins")
+	flag.IntVar(&authPacing, "auth-pacing", 21, "seconds between logins per proxy")
+	flag.IntVar(&authRetries, "auth-retries", 2, "login retries")
+	flag.IntVar(&loginTimeout, "login-timeout", int(mc.DefaultLoginTimeout.Seconds()), "login request timeout")
+	flag.StringVar(&caBundle, "ca-bundle", "", "extra trusted certificates")
+	flag.BoolVar(&insecureTLS, "insecure-skip-verify", false, "skip tls verification")
+	flag.IntVar(&workers, "workers", 0, "max concurrent requests")
+	flag.IntVar(&preciseMs, "precise", 0, "busy-wait window in ms")
+	flag.StringVar(&skin, "skin", "", "skin to apply after claiming")
+	flag.StringVar(&skinVariant, "skin-variant", "classic", "skin variant")
+	flag.BoolVar(&noVerify, "no-verify", false, "skip claim verification")
+	flag.StringVar(&historyPath, "history", claimer.DefaultHistoryPath, "claim history file")
+	flag.StringVar(&followUp, "follow-up", "", "username to snipe after claiming")
+	flag.StringVar(&followUpRange, "follow-up-range", "", "droptime range of the follow-up")
+	flag.StringVar(&webhooksPath, "webhooks", notify.DefaultConfigPath, "webhook config")
+	flag.StringVar(&watchlistCmd, "watchlist", "", "watchlist command")
+	flag.StringVar(&watchlistPath, "watchlist-file", watchlist.DefaultPath, "watchlist file")
+	flag.IntVar(&watchPriority, "priority", 0, "watchlist priority")
+	flag.StringVar(&watchNotes, "notes", "", "watchlist notes")
+	flag.StringVar(&watchAction, "action", string(watchlist.Alert), "watchlist action")
+	flag.StringVar(&selectExpr, "select", "", "account selector expression")
+	flag.IntVar(&watchInterval, "watch-interval", 60, "seconds between availability checks")
+	flag.StringVar(&accountsPath, "accounts", parser.DefaultAccountsPath, "accounts file")
+	flag.BoolVar(&accountsImport, "accounts-import", false, "import txt accounts")
+	flag.BoolVar(&accountsExport, "accounts-export", false, "export txt accounts")
+	flag.StringVar(&redeemPath, "redeem", "", "gift codes to redeem")
 
 	if isFlagPassed("disable-bar") {
 		disableBar = true
@@ -79,11 +199,133 @@ func runCli() {
 
 	flag.Parse()
 
+	mc.Login.Timeout = time.Duration(loginTimeout) * time.Second
+
+	if caBundle != "" {
+		pool, err := mc.LoadCABundle(caBundle)
+		if err != nil {
+			log.Log("err", "fatal: invalid ca bundle: %v", err)
+			return
+		}
+		mc.Login.RootCAs = pool
+	}
+
+	if insecureTLS {
+		mc.Login.Insecure = true
+		mc.Login.Warn = func(format string, args ...interface{}) {
+			log.Log("warn", format, args...)
+		}
+		log.Log("err", "INSECURE: tls verification is off, passwords and tokens can be read by anyone between you and microsoft. never use --insecure-skip-verify outside of debugging")
+	}
+
+	if accountsImport {
+		importAccounts()
+		return
+	}
+
+	if accountsExport {
+		exportAccounts()
+		return
+	}
+
 	if webMode {
+		webserver.AccountsPath = accountsPath
+		webserver.WebhooksPath = webhooksPath
+		webserver.WatchlistPath = watchlistPath
 		webserver.StartWebServer(webPort)
 		return
 	}
 
+	webhooks, err := notify.LoadConfig(webhooksPath)
+	if err != nil {
+		log.Log("err", "fatal: invalid webhook config: %v", err)
+		return
+	}
+	if len(webhooks.Webhooks) > 0 {
+		notify.NewNotifier(webhooks).Attach()
+		log.Log("info", "sending events to %d webhook(s)", len(webhooks.Webhooks))
+	}
+
+	scheduler, err := claimer.ParseScheduler(scheduleSpec)
+	if err != nil {
+		log.Log("err", "fatal: invalid schedule: %v", err)
+		return
+	}
+
+	if checkProxyMode {
+		checkProxies("proxies.txt", proxyTarget)
+		return
+	}
+
+	if lookupPath != "" {
+		lookupNames(lookupPath, lookupOut)
+		return
+	}
+
+	policy, err := claimer.ParseProxyPolicy(proxyPolicy)
+	if err != nil {
+		log.Log("err", "fatal: %v", err)
+		return
+	}
+
+	retries := authRetries
+	if retries == 0 { // AuthOptions reads 0 as the default
+		retries = -1
+	}
+
+	postClaim := claimer.PostClaimConfig{
+		Skin:        skin,
+		SkinVariant: skinVariant,
+		HistoryPath: historyPath,
+		FollowUp:    followUp,
+	}
+
+	if followUp != "" {
+		postClaim.FollowUpRange, err = log.ParseDropRange(followUpRange)
+		if err != nil {
+			log.Log("err", "fatal: invalid follow-up range: %v", err)
+			return
+		}
+	}
+
+	opts := claimer.Options{
+		CheckProxies:     dropDeadProxies,
+		ProxyCheckTarget: proxyTarget,
+		MaxProxyLatency:  time.Duration(maxProxyLatency) * time.Millisecond,
+		Prewarm:          time.Duration(prewarmSeconds) * time.Second,
+		Calibrate:        calibrate || ntpServer != "",
+		NTPServer:        ntpServer,
+		Scheduler:        scheduler,
+		LedgerPath:       ledgerPath,
+		Workers:          workers,
+		Precise:          time.Duration(preciseMs) * time.Millisecond,
+		PostClaim:        postClaim.Steps(),
+		SkipVerify:       noVerify,
+		ProxyPolicy:      policy,
+		WatchInterval:    time.Duration(watchInterval) * time.Second,
+		Auth: claimer.AuthOptions{
+			Concurrency: authConcurrency,
+			Pacing:      time.Duration(authPacing) * time.Second,
+			Retries:     retries,
+		},
+	}
+
+	if redeemPath != "" {
+		redeemGiftCodes(redeemPath, opts.Auth)
+		return
+	}
+
+	accountSelector, err := selector.Parse(selectExpr)
+	if err != nil {
+		log.Log("err", "fatal: invalid account selector: %v", err)
+		return
+	}
+
+	if watchlistCmd != "" {
+		runWatchlist(watchlistCmd, flag.Args(), opts)
+		return
+	}
+
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 
@@ -98,7 +340,7 @@ func runCli() {
 
 		log.Log("", log.GetHeader())
 
-		proxies, err := parser.ReadLines("proxies.txt")
+		proxies, err := getProxies("proxies.txt")
 
 		if err != nil {
 			log.Log("err", "failed to load proxies: %v", err)
@@ -114,6 +356,14 @@ func runCli() {
 			continue
 		}
 
+		accounts = accountSelector.Select(accounts)
+		if len(accounts) == 0 {
+			log.Log("err", "fatal: no accounts match %v", accountSelector)
+			log.Input("press enter to continue")
+			continue
+		}
+		log.Log("info", "using %d account(s) matching %v", len(accounts), accountSelector)
+
 		var username string
 
 		if !isFlagPassed("u", "username") {
@@ -145,7 +395,7 @@ func runCli() {
 			}
 		}()
 
-		err = claimer.ClaimWithinRange(username, dropRange, accounts, proxies)
+		err = claimer.ClaimWithinRange(username, dropRange, accounts, proxies, opts)
 
 		if err != nil {
 			log.Log("err", "fatal: %v", err)
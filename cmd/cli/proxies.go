@@ -0,0 +1,46 @@
+package main
+
+import (
+	"github.com/Kqzz/MCsniperGO/log"
+	"github.com/Kqzz/MCsniperGO/pkg/proxy"
+)
+
+// checkProxies runs a health check over every proxy in proxyPath and prints the results, fastest first.
+func checkProxies(proxyPath string, target string) {
+	proxies, err := getProxies(proxyPath)
+	if err != nil {
+		log.Log("err", "failed to load proxies: %v", err)
+		return
+	}
+
+	if len(proxies) == 0 {
+		log.Log("err", "no proxies in %s", proxyPath)
+		return
+	}
+
+	log.Log("info", "checking %d proxies against %s", len(proxies), target)
+
+	results := proxy.NewChecker(target).CheckAll(proxies)
+
+	byProxy := map[*proxy.Proxy]proxy.CheckResult{}
+	for _, r := range results {
+		byProxy[r.Proxy] = r
+		if !r.Alive {
+			log.Log("err", "%v dead: %v", r.Proxy.Redacted(), r.Err)
+		}
+	}
+
+	ranked := proxy.Rank(results)
+	for _, p := range ranked {
+		r := byProxy[p]
+		log.Log("success", "%v connect %vms | tls %vms | request %vms | total %vms",
+			p.Redacted(),
+			r.Connect.Milliseconds(),
+			r.Handshake.Milliseconds(),
+			r.Request.Milliseconds(),
+			r.Latency().Milliseconds(),
+		)
+	}
+
+	log.Log("info", "%d/%d proxies alive", len(ranked), len(proxies))
+}
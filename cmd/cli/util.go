@@ -1,17 +1,28 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"os"
 
 	"github.com/Kqzz/MCsniperGO/log"
 	mc "github.com/Kqzz/MCsniperGO/pkg/mc"
 	"github.com/Kqzz/MCsniperGO/pkg/parser"
+	"github.com/Kqzz/MCsniperGO/pkg/proxy"
 )
 
-// getAccounts parses accounts from the specified files.
+// getAccounts parses accounts from the specified files, or from the accounts file when there is one.
 // It logs errors during parsing but returns successfully even if some files fail,
 // unless NO accounts are successfully parsed.
 func getAccounts(giftCodePath string, gamepassPath string, microsoftPath string) ([]*mc.MCaccount, error) {
+	records, err := parser.LoadAccountsFile(accountsPath)
+	if err == nil {
+		return getRecordAccounts(records)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to load %s: %v", accountsPath, err)
+	}
+
 	giftCodeLines, _ := parser.ReadLines(giftCodePath)
 	gamepassLines, _ := parser.ReadLines(gamepassPath)
 	microsoftLines, _ := parser.ReadLines(microsoftPath)
@@ -50,3 +61,32 @@ func getAccounts(giftCodePath string, gamepassPath string, microsoftPath string)
 	log.Log("succ", "Successfully parsed %d accounts", len(accounts))
 	return accounts, nil
 }
+
+func getRecordAccounts(records []parser.AccountRecord) ([]*mc.MCaccount, error) {
+	accounts, errs := parser.RecordAccounts(records)
+	for _, er := range errs {
+		log.Log("err", "Account parsing error: %v", er)
+	}
+
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("no enabled accounts successfully parsed from: %s", accountsPath)
+	}
+
+	log.Log("succ", "Successfully parsed %d accounts from %s", len(accounts), accountsPath)
+	return accounts, nil
+}
+
+// getProxies parses proxies from the specified file, logging any lines that fail to parse.
+func getProxies(proxyPath string) ([]*proxy.Proxy, error) {
+	lines, err := parser.ReadLines(proxyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	proxies, parseErrors := proxy.ParseList(lines)
+	for _, er := range parseErrors {
+		log.Log("err", "Proxy parsing error: %v", er)
+	}
+
+	return proxies, nil
+}
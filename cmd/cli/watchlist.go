@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Kqzz/MCsniperGO/claimer"
+	"github.com/Kqzz/MCsniperGO/log"
+	"github.com/Kqzz/MCsniperGO/pkg/watchlist"
+)
+
+// runWatchlist handles --watchlist add/remove/list/run, names are the positional arguments
+func runWatchlist(cmd string, names []string, opts claimer.Options) {
+	store, err := watchlist.Load(watchlistPath)
+	if err != nil {
+		log.Log("err", "failed to load %s: %v", watchlistPath, err)
+		return
+	}
+
+	switch cmd {
+	case "add":
+		for _, name := range names {
+			entry := watchlist.Entry{
+				Name:     name,
+				Priority: watchPriority,
+				Notes:    watchNotes,
+				Action:   watchlist.Action(watchAction),
+				Select:   selectExpr,
+			}
+			if err := store.Put(entry); err != nil {
+				log.Log("err", "failed to add %v: %v", name, err)
+				continue
+			}
+			log.Log("success", "watching %v (%v, priority %d)", name, entry.Action, entry.Priority)
+		}
+
+	case "remove":
+		for _, name := range names {
+			if err := store.Remove(name); err != nil {
+				log.Log("err", "%v", err)
+				continue
+			}
+			log.Log("success", "stopped watching %v", name)
+		}
+
+	case "list":
+		entries := store.List()
+		if len(entries) == 0 {
+			log.Log("info", "the watchlist is empty")
+		}
+		for _, e := range entries {
+			accounts := e.Select
+			if accounts == "" {
+				accounts = "all"
+			}
+			log.Log("info", "%-16v priority %-3d %-6v %-9v accounts: %v %v", e.Name, e.Priority, e.Action, e.State, accounts, e.Notes)
+		}
+
+	case "run":
+		interval := time.Duration(watchInterval) * time.Second
+		runner := watchlist.NewRunner(store, interval, func(entry watchlist.Entry) error {
+			return snipeWatched(entry, opts)
+		})
+
+		log.Log("info", "watching %d name(s), each checked every %v", len(store.List()), interval)
+		runner.Start()
+		select {}
+
+	default:
+		log.Log("err", "unknown watchlist command %q, use add, remove, list or run", cmd)
+	}
+}
+
+// snipeWatched snipes entry for watchlist.SnipeWindow with the accounts its selector picks
+func snipeWatched(entry watchlist.Entry, opts claimer.Options) error {
+	accounts, err := getAccounts("gc.txt", "gp.txt", "ms.txt")
+	if err != nil {
+		return err
+	}
+
+	accounts, err = entry.Accounts(accounts)
+	if err != nil {
+		return err
+	}
+	if len(accounts) == 0 {
+		return fmt.Errorf("no accounts match %q", entry.Select)
+	}
+
+	proxies, err := getProxies("proxies.txt")
+	if err != nil {
+		log.Log("err", "failed to load proxies: %v", err)
+	}
+
+	opts.StartAfterAuth = true
+	return claimer.ClaimWithinRange(entry.Name, watchlist.SnipeRange(), accounts, proxies, opts)
+}
@@ -2,13 +2,15 @@ module github.com/Kqzz/MCsniperGO
 
 go 1.17
 
-require github.com/gookit/color v1.5.4
+require (
+	github.com/gookit/color v1.5.4
+	golang.org/x/net v0.14.0
+)
 
 require (
 	github.com/andybalholm/brotli v1.0.5 // indirect
 	github.com/klauspost/compress v1.16.7 // indirect
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
-	golang.org/x/net v0.14.0 // indirect
 	golang.org/x/text v0.12.0 // indirect
 )
 
@@ -2,6 +2,7 @@ package log
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"math/rand"
 	"os"
@@ -87,35 +88,40 @@ func PrettyStatus(status int) string {
 
 func GetDropRange() mc.DropRange {
 	for {
-		rawDroptimes := Input("droptime range (start-end/infinite)")
-
-		if rawDroptimes == "inf" || rawDroptimes == "infinite" {
-			return mc.DropRange{Start: time.Time{}, End: time.Time{}}
+		dropRange, err := ParseDropRange(Input("droptime range (start-end/infinite)"))
+		if err != nil {
+			Log("err", "%v", err)
+			continue
 		}
+		return dropRange
+	}
 
-		rawDroptimesSplit := strings.Split(rawDroptimes, "-")
+}
 
-		if len(rawDroptimesSplit) != 2 {
-			Log("err", "invalid droptime range")
-			continue
-		}
+// ParseDropRange reads a "start-end" pair of unix timestamps, or "inf"/"infinite" for a range with no bounds
+func ParseDropRange(rawDroptimes string) (mc.DropRange, error) {
+	if rawDroptimes == "inf" || rawDroptimes == "infinite" {
+		return mc.DropRange{Start: time.Time{}, End: time.Time{}}, nil
+	}
 
-		startDroptimeNum, err := strconv.Atoi(rawDroptimesSplit[0])
-		if err != nil {
-			Log("err", "invalid droptime start")
-			continue
-		}
-		endDroptimeNum, err := strconv.Atoi(rawDroptimesSplit[1])
-		if err != nil {
-			Log("err", "invalid droptime end")
-			continue
-		}
-		startDroptime := time.Unix(int64(startDroptimeNum), 0)
-		endDroptime := time.Unix(int64(endDroptimeNum), 0)
+	rawDroptimesSplit := strings.Split(rawDroptimes, "-")
+
+	if len(rawDroptimesSplit) != 2 {
+		return mc.DropRange{}, errors.New("invalid droptime range")
+	}
 
-		return mc.DropRange{Start: startDroptime, End: endDroptime}
+	startDroptimeNum, err := strconv.Atoi(rawDroptimesSplit[0])
+	if err != nil {
+		return mc.DropRange{}, errors.New("invalid droptime start")
+	}
+	endDroptimeNum, err := strconv.Atoi(rawDroptimesSplit[1])
+	if err != nil {
+		return mc.DropRange{}, errors.New("invalid droptime end")
 	}
+	startDroptime := time.Unix(int64(startDroptimeNum), 0)
+	endDroptime := time.Unix(int64(endDroptimeNum), 0)
 
+	return mc.DropRange{Start: startDroptime, End: endDroptime}, nil
 }
 
 func LastQuarter(s string) string {
@@ -0,0 +1,242 @@
+package availability
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Kqzz/MCsniperGO/log"
+	"github.com/Kqzz/MCsniperGO/pkg/mc"
+)
+
+const (
+	DefaultInterval = time.Minute
+	maxBackoff      = time.Minute * 10
+)
+
+type State string
+
+const (
+	Unknown   State = "unknown" // not checked yet, or every check so far failed
+	Available State = "available"
+	Taken     State = "taken"
+)
+
+// Change is a name moving from one state to another, the first successful check of a name is a change from Unknown
+type Change struct {
+	Name string
+	From State
+	To   State
+	UUID string // owner of the name when it's taken
+	Time time.Time
+}
+
+// Watcher polls the profile lookup for a set of names and reports when they become available or get taken.
+// every name is checked once per Interval, a 429 from the lookup pauses all checks with an exponential backoff.
+type Watcher struct {
+	Interval time.Duration
+	Lookup   func(name string) (mc.ProfileResponse, int, error) // mc.UsernameToUuid if nil
+
+	mu      sync.Mutex
+	names   map[string]State
+	order   []string
+	subs    []func(Change)
+	stop    chan struct{}
+	running bool
+}
+
+func NewWatcher(interval time.Duration, names ...string) *Watcher {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	w := &Watcher{
+		Interval: interval,
+		names:    map[string]State{},
+	}
+	w.Add(names...)
+	return w
+}
+
+func key(name string) string {
+	return strings.ToLower(name)
+}
+
+// Add starts watching names, ignoring ones already watched
+func (w *Watcher) Add(names ...string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, name := range names {
+		if _, ok := w.names[key(name)]; ok || name == "" {
+			continue
+		}
+		w.names[key(name)] = Unknown
+		w.order = append(w.order, name)
+	}
+}
+
+func (w *Watcher) Remove(name string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.names, key(name))
+	for i, watched := range w.order {
+		if key(watched) == key(name) {
+			w.order = append(w.order[:i], w.order[i+1:]...)
+			break
+		}
+	}
+}
+
+func (w *Watcher) Names() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string{}, w.order...)
+}
+
+// State returns the last known state of name, Unknown if it isn't watched
+func (w *Watcher) State(name string) State {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if state, ok := w.names[key(name)]; ok {
+		return state
+	}
+	return Unknown
+}
+
+// Subscribe registers fn to be called on every change. fn runs on the watcher's goroutine and should not block.
+func (w *Watcher) Subscribe(fn func(Change)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subs = append(w.subs, fn)
+}
+
+// Start begins polling in the background, it does nothing if the watcher is already running
+func (w *Watcher) Start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.running {
+		return
+	}
+	w.running = true
+	w.stop = make(chan struct{})
+	go w.run(w.stop)
+}
+
+func (w *Watcher) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.running {
+		return
+	}
+	w.running = false
+	close(w.stop)
+}
+
+// sleep waits for d, false if the watcher was stopped meanwhile
+func sleep(d time.Duration, stop chan struct{}) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-stop:
+		return false
+	}
+}
+
+func (w *Watcher) run(stop chan struct{}) {
+	backoff := time.Duration(0)
+
+	for {
+		names := w.Names()
+		if len(names) == 0 {
+			if !sleep(w.Interval, stop) {
+				return
+			}
+			continue
+		}
+
+		gap := w.Interval / time.Duration(len(names))
+		for _, name := range names {
+			for {
+				limited := w.check(name)
+				if !limited {
+					backoff = 0
+					break
+				}
+
+				if backoff == 0 {
+					backoff = w.Interval
+				} else if backoff *= 2; backoff > maxBackoff {
+					backoff = maxBackoff
+				}
+				log.Log("warn", "availability lookups are ratelimited, retrying in %v", backoff)
+				if !sleep(backoff, stop) {
+					return
+				}
+			}
+
+			if !sleep(gap, stop) {
+				return
+			}
+		}
+	}
+}
+
+// check looks name up once, returning true if the lookup was ratelimited
+func (w *Watcher) check(name string) bool {
+	lookup := w.Lookup
+	if lookup == nil {
+		lookup = mc.UsernameToUuid
+	}
+
+	profile, status, err := lookup(name)
+
+	var state State
+	switch status {
+	case 200:
+		if err != nil {
+			log.Log("err", "failed to read availability of %v: %v", name, err)
+			return false
+		}
+		state = Taken
+	case 204, 404:
+		state = Available
+	case 429:
+		return true
+	default:
+		if err != nil {
+			log.Log("err", "failed to check availability of %v: %v", name, err)
+		} else {
+			log.Log("err", "availability check of %v answered %v", name, status)
+		}
+		return false
+	}
+
+	w.set(name, state, profile.ID)
+	return false
+}
+
+func (w *Watcher) set(name string, state State, uuid string) {
+	w.mu.Lock()
+	from, ok := w.names[key(name)]
+	if !ok || from == state {
+		w.mu.Unlock()
+		return
+	}
+	w.names[key(name)] = state
+
+	change := Change{Name: name, From: from, To: state, Time: time.Now()}
+	if state == Taken {
+		change.UUID = uuid
+	}
+	subs := w.subs
+	w.mu.Unlock()
+
+	for _, fn := range subs {
+		fn(change)
+	}
+}
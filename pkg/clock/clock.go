@@ -0,0 +1,212 @@
+package clock
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+const DefaultUrl = "https://api.minecraftservices.com/"
+
+// result of comparing the local clock to a remote one
+type Calibration struct {
+	Offset  time.Duration // remote clock minus local clock, positive when the remote clock is ahead
+	Latency time.Duration // estimated one-way latency to the remote host
+	Samples int           // samples that contributed to the estimate
+	Source  string
+}
+
+// ServerTime converts a local time to what the remote clock reads at that moment
+func (c Calibration) ServerTime(t time.Time) time.Time {
+	return t.Add(c.Offset)
+}
+
+// LocalTime converts a remote clock time to the local time it happens at
+func (c Calibration) LocalTime(t time.Time) time.Time {
+	return t.Add(-c.Offset)
+}
+
+/*
+	the Date header only has 1s resolution, so a single sample only tells us the offset is somewhere in
+	(date - received, date + 1s - sent). samples are spread over the second so their windows land at different
+	phases, and the intersection of all windows narrows the offset down to roughly the round trip jitter.
+*/
+func FromHTTP(url string, samples int, client *fasthttp.Client) (Calibration, error) {
+	if url == "" {
+		url = DefaultUrl
+	}
+	if samples < 1 {
+		samples = 20
+	}
+	if client == nil {
+		client = &fasthttp.Client{}
+	}
+
+	var low, high time.Duration
+	var midSum, rttSum time.Duration
+	intersects := true
+	n := 0
+
+	for i := 0; i < samples; i++ {
+		if i != 0 {
+			time.Sleep(time.Second/time.Duration(samples) + time.Millisecond*7)
+		}
+
+		req := fasthttp.AcquireRequest()
+		resp := fasthttp.AcquireResponse()
+		req.SetRequestURI(url)
+		req.Header.SetMethod("HEAD")
+
+		sent := time.Now()
+		err := client.DoTimeout(req, resp, time.Second*5)
+		received := time.Now()
+
+		date := string(resp.Header.Peek("Date"))
+		fasthttp.ReleaseRequest(req)
+		fasthttp.ReleaseResponse(resp)
+
+		if err != nil {
+			continue
+		}
+
+		serverTime, err := http.ParseTime(date)
+		if err != nil {
+			continue
+		}
+
+		sampleLow := serverTime.Sub(received)
+		sampleHigh := serverTime.Add(time.Second).Sub(sent)
+
+		if n == 0 {
+			low, high = sampleLow, sampleHigh
+		} else {
+			if sampleLow > low {
+				low = sampleLow
+			}
+			if sampleHigh < high {
+				high = sampleHigh
+			}
+		}
+		if low > high {
+			intersects = false
+		}
+
+		midSum += (sampleLow + sampleHigh) / 2
+		rttSum += received.Sub(sent)
+		n++
+	}
+
+	if n == 0 {
+		return Calibration{}, errors.New("no usable Date headers from " + url)
+	}
+
+	offset := midSum / time.Duration(n)
+	if intersects {
+		offset = (low + high) / 2
+	}
+
+	return Calibration{
+		Offset:  offset,
+		Latency: rttSum / time.Duration(n) / 2,
+		Samples: n,
+		Source:  url,
+	}, nil
+}
+
+// seconds between the ntp epoch (1900) and the unix epoch
+const ntpEpochOffset = 2208988800
+
+// replies claiming the local clock is off by more than this are treated as bogus
+const maxNTPOffset = time.Hour
+
+// checkNTPReply rejects packets that aren't a server reply carrying a usable time
+func checkNTPReply(packet []byte) error {
+	if mode := packet[0] & 0x7; mode != 4 {
+		return fmt.Errorf("reply has mode %d, not a server reply", mode)
+	}
+	if packet[1] == 0 {
+		return errors.New("kiss-o'-death reply (stratum 0)")
+	}
+	if binary.BigEndian.Uint64(packet[40:48]) == 0 {
+		return errors.New("reply has no transmit timestamp")
+	}
+	return nil
+}
+
+func ntpTime(b []byte) time.Time {
+	secs := binary.BigEndian.Uint32(b[0:4])
+	frac := binary.BigEndian.Uint32(b[4:8])
+	nanos := (int64(frac) * 1e9) >> 32
+	return time.Unix(int64(secs)-ntpEpochOffset, nanos)
+}
+
+// FromSNTP queries an sntp server (host or host:port) and keeps the sample with the lowest round trip.
+func FromSNTP(server string, samples int) (Calibration, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "123")
+	}
+	if samples < 1 {
+		samples = 4
+	}
+
+	best := Calibration{Source: "ntp://" + server}
+	bestDelay := time.Duration(-1)
+	var lastErr error
+
+	for i := 0; i < samples; i++ {
+		conn, err := net.DialTimeout("udp", server, time.Second*5)
+		if err != nil {
+			return Calibration{}, err
+		}
+
+		packet := make([]byte, 48)
+		packet[0] = 0x1b // li 0, version 3, mode 3 (client)
+
+		conn.SetDeadline(time.Now().Add(time.Second * 5))
+
+		t1 := time.Now()
+		_, err = conn.Write(packet)
+		if err == nil {
+			_, err = conn.Read(packet)
+		}
+		t4 := time.Now()
+		conn.Close()
+
+		if err == nil {
+			err = checkNTPReply(packet)
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		t2 := ntpTime(packet[32:40]) // receive timestamp
+		t3 := ntpTime(packet[40:48]) // transmit timestamp
+
+		delay := t4.Sub(t1) - t3.Sub(t2)
+		offset := (t2.Sub(t1) + t3.Sub(t4)) / 2
+
+		if offset > maxNTPOffset || offset < -maxNTPOffset {
+			lastErr = fmt.Errorf("reply puts the clock %v off, more than %v", offset, maxNTPOffset)
+			continue
+		}
+
+		best.Samples++
+		if bestDelay == -1 || delay < bestDelay {
+			bestDelay = delay
+			best.Offset = offset
+			best.Latency = delay / 2
+		}
+	}
+
+	if best.Samples == 0 {
+		return Calibration{}, fmt.Errorf("no usable replies from %s: %v", server, lastErr)
+	}
+
+	return best, nil
+}
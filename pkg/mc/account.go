@@ -7,6 +7,9 @@ import (
 	"fmt"
 	"io"
 	"math/rand"
+	"mime/multipart"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -83,66 +86,14 @@ func (account *MCaccount) LoadAccountInfo() error {
 	return nil
 }
 
+// HasGcApplied checks the account owns minecraft and has no profile yet, so it can claim a name by creating one.
+// only reads the account's entitlements and profile, nothing is created.
 func (account *MCaccount) HasGcApplied() (bool, error) {
-	bodyStr := `{"profileName": "test"}`
-
-	req, resp, err := account.AuthenticatedReq("POST", "https://api.minecraftservices.com/minecraft/profile", bytes.NewReader([]byte(bodyStr)))
-	if err != nil {
-		return false, err
-	}
-
-	err = account.FastHttpClient.Do(req, resp)
-	if err != nil {
-		return false, err
-	}
-
-	bodyBytes := resp.Body()
+	status, err := account.CheckEntitlement()
 	if err != nil {
 		return false, err
 	}
-
-	statusCode := resp.StatusCode()
-
-	if statusCode == 200 {
-		return false, errors.New("successfully created profile with name test. unintended behavior, function is meant to check if gc is applied")
-
-	} else if statusCode == 401 {
-		return false, errors.New("received unauthorized response")
-	} else if statusCode == 400 {
-		var respError hasGcAppliedResp
-
-		err = json.Unmarshal(bodyBytes, &respError)
-		if err != nil {
-			return false, err
-		}
-
-		var hasGc bool
-
-		switch respError.Details.Status {
-		case "ALREADY_REGISTERED", "NOT_ENTITLED":
-			{
-				hasGc = false
-			}
-		case "DUPLICATE", "NOT_ALLOWED":
-			{
-				hasGc = true
-			}
-		default:
-			{
-				hasGc = false
-			}
-		}
-
-		return hasGc, nil
-
-	}
-
-	if strings.Contains(string(bodyBytes), "Request blocked") {
-		return false, errors.New("blocked by cloudfront (ip block)")
-	}
-
-	return false, fmt.Errorf("got status: %v body: %v", statusCode, string(bodyBytes))
-
+	return status.ReadyForProfile(), nil
 }
 
 // grab information on the availability of name change for this account
@@ -321,3 +272,43 @@ func (account *MCaccount) ChangeSkinFromUrl(url, variant string) error {
 
 	return nil
 }
+
+// upload a skin from a local png, variant is "classic" or "slim"
+func (account *MCaccount) ChangeSkinFromFile(path, variant string) error {
+	skin, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	form.WriteField("variant", variant)
+
+	file, err := form.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	file.Write(skin)
+	form.Close()
+
+	req, resp, err := account.AuthenticatedReq("POST", "https://api.minecraftservices.com/minecraft/profile/skins", body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	err = account.FastHttpClient.Do(req, resp)
+
+	if err != nil {
+		return err
+	}
+
+	statusCode := resp.StatusCode()
+
+	if statusCode != 200 {
+		return fmt.Errorf("failed with status: %v", statusCode)
+	}
+
+	return nil
+}
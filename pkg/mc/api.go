@@ -2,7 +2,9 @@ package mc
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/valyala/fasthttp"
 )
@@ -38,3 +40,155 @@ func UsernameToUuid(username string) (ProfileResponse, int, error) {
 
 	return profile, statusCode, nil
 }
+
+const (
+	ProfilesBatchSize  = 10 // most names the batch endpoint takes per request
+	DefaultProfilesUrl = "https://api.mojang.com/profiles/minecraft"
+)
+
+var ErrLookupRatelimited = errors.New("profile lookup is ratelimited")
+
+// ErrInvalidName is the Err of a lookup of a name minecraft doesn't allow, those are never sent
+var ErrInvalidName = errors.New("not a valid minecraft name")
+
+// ValidName is true for names minecraft allows, 3 to 16 letters, digits or underscores
+func ValidName(name string) bool {
+	if len(name) < 3 || len(name) > 16 {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// a name and whether anyone owns it, as found by a batch lookup
+type NameLookup struct {
+	Name      string
+	UUID      string // empty when the name is available
+	Available bool
+	Err       error // why the name couldn't be looked up, UUID and Available mean nothing then
+}
+
+// resolves names in batches through the POST profiles endpoint
+type BatchLookup struct {
+	Client *fasthttp.Client // set its Dial to go through a proxy, fasthttp's default client if nil
+	Url    string           // DefaultProfilesUrl if empty, point it elsewhere to use a mock
+}
+
+func NewBatchLookup(client *fasthttp.Client) *BatchLookup {
+	return &BatchLookup{Client: client, Url: DefaultProfilesUrl}
+}
+
+func (l *BatchLookup) do(req *fasthttp.Request, resp *fasthttp.Response) error {
+	if l.Client == nil {
+		return fasthttp.Do(req, resp)
+	}
+	return l.Client.Do(req, resp)
+}
+
+// Batch looks up at most ProfilesBatchSize names in one request, in the order given.
+// invalid names aren't sent, their lookup carries ErrInvalidName.
+func (l *BatchLookup) Batch(names []string) ([]NameLookup, error) {
+	if len(names) > ProfilesBatchSize {
+		return nil, fmt.Errorf("can't look up more than %d names per request, got %d", ProfilesBatchSize, len(names))
+	}
+
+	valid := []string{}
+	for _, name := range names {
+		if ValidName(name) {
+			valid = append(valid, name)
+		}
+	}
+
+	owners, err := l.owners(valid)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []NameLookup{}
+	for _, name := range names {
+		if !ValidName(name) {
+			results = append(results, NameLookup{Name: name, Err: ErrInvalidName})
+			continue
+		}
+		uuid := owners[strings.ToLower(name)]
+		results = append(results, NameLookup{Name: name, UUID: uuid, Available: uuid == ""})
+	}
+	return results, nil
+}
+
+// owners maps the lowercased names that are taken to their uuid
+func (l *BatchLookup) owners(names []string) (map[string]string, error) {
+	owners := map[string]string{}
+	if len(names) == 0 {
+		return owners, nil
+	}
+
+	body, err := json.Marshal(names)
+	if err != nil {
+		return nil, err
+	}
+
+	url := l.Url
+	if url == "" {
+		url = DefaultProfilesUrl
+	}
+
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(url)
+	req.Header.SetMethod("POST")
+	req.Header.SetContentType("application/json")
+	req.SetBody(body)
+
+	err = l.do(req, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode() {
+	case 200:
+	case 429:
+		return nil, ErrLookupRatelimited
+	default:
+		return nil, fmt.Errorf("profile lookup answered %v: %s", resp.StatusCode(), resp.Body())
+	}
+
+	profiles := []ProfileResponse{}
+	err = json.Unmarshal(resp.Body(), &profiles)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, profile := range profiles {
+		owners[strings.ToLower(profile.Name)] = profile.ID
+	}
+	return owners, nil
+}
+
+// Lookup resolves every name, ProfilesBatchSize per request, in the order given.
+// on an error the names resolved so far are returned along with it.
+func (l *BatchLookup) Lookup(names []string) ([]NameLookup, error) {
+	results := []NameLookup{}
+
+	for start := 0; start < len(names); start += ProfilesBatchSize {
+		end := start + ProfilesBatchSize
+		if end > len(names) {
+			end = len(names)
+		}
+
+		batch, err := l.Batch(names[start:end])
+		if err != nil {
+			return results, err
+		}
+		results = append(results, batch...)
+	}
+
+	return results, nil
+}
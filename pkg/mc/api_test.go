@@ -0,0 +1,126 @@
+package mc
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// profilesServer answers like the batch endpoint, owners maps lowercased names to their uuid
+func profilesServer(t *testing.T, owners map[string]string, sent *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		names := []string{}
+		if err := json.NewDecoder(r.Body).Decode(&names); err != nil {
+			t.Errorf("bad request body: %v", err)
+		}
+		*sent = append(*sent, names...)
+
+		profiles := []ProfileResponse{}
+		for _, name := range names {
+			if id, ok := owners[strings.ToLower(name)]; ok {
+				profiles = append(profiles, ProfileResponse{Name: name, ID: id})
+			}
+		}
+		json.NewEncoder(w).Encode(profiles)
+	}))
+}
+
+func TestBatch(t *testing.T) {
+	sent := []string{}
+	server := profilesServer(t, map[string]string{"taken": "0123456789abcdef0123456789abcdef"}, &sent)
+	defer server.Close()
+
+	lookup := &BatchLookup{Url: server.URL}
+	got, err := lookup.Batch([]string{"Taken", "free_name", "no", "has space", "waytoolongforaname"})
+	if err != nil {
+		t.Fatalf("Batch failed: %v", err)
+	}
+
+	want := []NameLookup{
+		{Name: "Taken", UUID: "0123456789abcdef0123456789abcdef"},
+		{Name: "free_name", Available: true},
+		{Name: "no", Err: ErrInvalidName},
+		{Name: "has space", Err: ErrInvalidName},
+		{Name: "waytoolongforaname", Err: ErrInvalidName},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Batch returned %d lookups, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("lookup %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if strings.Join(sent, ",") != "Taken,free_name" {
+		t.Errorf("sent %v, invalid names shouldn't be sent", sent)
+	}
+}
+
+func TestBatchOnlyInvalid(t *testing.T) {
+	sent := []string{}
+	server := profilesServer(t, nil, &sent)
+	defer server.Close()
+
+	lookup := &BatchLookup{Url: server.URL}
+	got, err := lookup.Batch([]string{"a", "b!"})
+	if err != nil {
+		t.Fatalf("Batch failed: %v", err)
+	}
+	for _, r := range got {
+		if !errors.Is(r.Err, ErrInvalidName) || r.Available {
+			t.Errorf("lookup of %q = %+v, want ErrInvalidName", r.Name, r)
+		}
+	}
+	if len(sent) != 0 {
+		t.Errorf("sent %v with no valid names", sent)
+	}
+}
+
+func TestBatchErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusTooManyRequests, ErrLookupRatelimited},
+		{http.StatusInternalServerError, nil},
+	}
+
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+		}))
+
+		lookup := &BatchLookup{Url: server.URL}
+		got, err := lookup.Batch([]string{"some_name"})
+		server.Close()
+
+		if err == nil {
+			t.Errorf("status %d: Batch = %+v, want an error", tt.status, got)
+			continue
+		}
+		if tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("status %d: Batch error = %v, want %v", tt.status, err, tt.want)
+		}
+	}
+}
+
+func TestValidName(t *testing.T) {
+	for name, want := range map[string]bool{
+		"abc":               true,
+		"Notch":             true,
+		"under_score_16chr": false,
+		"under_score_16ch":  true,
+		"ab":                false,
+		"dash-name":         false,
+		"":                  false,
+		"ñame":              false,
+	} {
+		if got := ValidName(name); got != want {
+			t.Errorf("ValidName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package mc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/valyala/fasthttp"
+)
+
+// gift code failures that retrying won't fix
+var (
+	ErrGiftCodeInvalid  = errors.New("gift code is invalid")
+	ErrGiftCodeRejected = errors.New("gift code was rejected")
+	ErrHasProfile       = errors.New("account already has a profile")
+	ErrNoGiftCode       = errors.New("account doesn't own minecraft, its gift code isn't redeemed")
+)
+
+// entitlements that mean the account owns minecraft: java edition
+var minecraftEntitlements = map[string]bool{
+	"product_minecraft": true,
+	"game_minecraft":    true,
+}
+
+type Entitlement struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+}
+
+type entitlementsResponse struct {
+	Items []Entitlement `json:"items"`
+}
+
+// EntitlementStatus is what an account owns and whether it has a profile yet
+type EntitlementStatus struct {
+	Entitlements  []Entitlement
+	OwnsMinecraft bool
+	HasProfile    bool
+	Username      string // empty without a profile
+}
+
+// ReadyForProfile is true for an account that can claim a name by creating its profile, e.g. one with a redeemed gift code
+func (s EntitlementStatus) ReadyForProfile() bool {
+	return s.OwnsMinecraft && !s.HasProfile
+}
+
+// Entitlements lists what the account owns, without changing anything on it
+func (account *MCaccount) Entitlements() ([]Entitlement, error) {
+	req, resp, err := account.AuthenticatedReq("GET", "https://api.minecraftservices.com/entitlements/mcstore", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	err = account.httpClient().Do(req, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode() != 200 {
+		return nil, fmt.Errorf("failed w/ status: %v", resp.StatusCode())
+	}
+
+	var respJson entitlementsResponse
+	err = json.Unmarshal(resp.Body(), &respJson)
+	if err != nil {
+		return nil, err
+	}
+	return respJson.Items, nil
+}
+
+// CheckEntitlement looks up what the account owns and its profile, without changing anything on it
+func (account *MCaccount) CheckEntitlement() (EntitlementStatus, error) {
+	entitlements, err := account.Entitlements()
+	if err != nil {
+		return EntitlementStatus{}, err
+	}
+
+	status := EntitlementStatus{Entitlements: entitlements}
+	for _, e := range entitlements {
+		if minecraftEntitlements[e.Name] {
+			status.OwnsMinecraft = true
+		}
+	}
+
+	req, resp, err := account.AuthenticatedReq("GET", "https://api.minecraftservices.com/minecraft/profile", nil)
+	if err != nil {
+		return status, err
+	}
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	err = account.httpClient().Do(req, resp)
+	if err != nil {
+		return status, err
+	}
+
+	switch resp.StatusCode() {
+	case 200:
+		var respJson accInfoResponse
+		json.Unmarshal(resp.Body(), &respJson)
+		status.HasProfile = true
+		status.Username = respJson.Name
+		account.Username = respJson.Name
+		account.UUID = respJson.ID
+	case 404: // no profile yet
+	default:
+		return status, fmt.Errorf("profile lookup failed w/ status: %v", resp.StatusCode())
+	}
+	return status, nil
+}
+
+// RedeemGiftCode redeems a minecraft gift code onto the account
+func (account *MCaccount) RedeemGiftCode(code string) error {
+	req, resp, err := account.AuthenticatedReq("PUT", "https://api.minecraftservices.com/productvoucher/"+url.PathEscape(code), nil)
+	if err != nil {
+		return err
+	}
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	err = account.httpClient().Do(req, resp)
+	if err != nil {
+		return err
+	}
+
+	statusCode := resp.StatusCode()
+	switch {
+	case statusCode == 200 || statusCode == 204:
+		return nil
+	case statusCode == 404:
+		return ErrGiftCodeInvalid
+	case statusCode == 401:
+		return errors.New("received unauthorized response")
+	case statusCode == 429:
+		return errors.New("ratelimited")
+	case statusCode >= 400 && statusCode < 500:
+		var respError hasGcAppliedResp
+		json.Unmarshal(resp.Body(), &respError)
+		if respError.ErrorMessage != "" {
+			return fmt.Errorf("%w: %v", ErrGiftCodeRejected, respError.ErrorMessage)
+		}
+		return fmt.Errorf("%w: status %v", ErrGiftCodeRejected, statusCode)
+	}
+	return fmt.Errorf("failed w/ status: %v", statusCode)
+}
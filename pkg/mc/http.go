@@ -1,11 +1,10 @@
 package mc
 
 import (
-	"strings"
 	"time"
 
+	"github.com/Kqzz/MCsniperGO/pkg/proxy"
 	"github.com/valyala/fasthttp"
-	"github.com/valyala/fasthttp/fasthttpproxy"
 )
 
 func (account *MCaccount) DefaultFastHttpHandler() {
@@ -18,16 +17,17 @@ func (account *MCaccount) DefaultFastHttpHandler() {
 	}
 }
 
-func (account *MCaccount) SetProxy(proxy string) {
-
-	if strings.HasPrefix(proxy, "socks") {
-		account.FastHttpClient.Dial = fasthttpproxy.FasthttpSocksDialer(proxy)
-	} else {
-		if strings.HasPrefix(proxy, "http") {
-			proxy = strings.TrimPrefix(proxy, "http://")
-			proxy = strings.TrimPrefix(proxy, "https://")
+// httpClient is the account's client, a default one through its bound proxy if it has none yet
+func (account *MCaccount) httpClient() *fasthttp.Client {
+	if account.FastHttpClient == nil {
+		account.DefaultFastHttpHandler()
+		if account.Proxy != nil {
+			account.SetProxy(account.Proxy)
 		}
-		account.FastHttpClient.Dial = fasthttpproxy.FasthttpHTTPDialer(proxy)
 	}
+	return account.FastHttpClient
+}
 
+func (account *MCaccount) SetProxy(p *proxy.Proxy) {
+	account.FastHttpClient.Dial = p.Dialer()
 }
@@ -0,0 +1,263 @@
+package mc
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Kqzz/MCsniperGO/pkg/proxy"
+)
+
+// LoginEndpoints are the urls the login chain talks to
+type LoginEndpoints struct {
+	Authorize     string // live.com page holding the credential form
+	XBL           string // xbox live user token
+	XSTS          string // xsts token for minecraft services
+	LoginWithXbox string // trades the xsts token for a minecraft bearer
+	DeviceCode    string // starts the device code flow
+	DeviceToken   string // polled until the device code is entered
+}
+
+var DefaultLoginEndpoints = LoginEndpoints{
+	Authorize:     "https://login.live.com/oauth20_authorize.srf?client_id=000000004C12AE6F&redirect_uri=https://login.live.com/oauth20_desktop.srf&scope=service::user.auth.xboxlive.com::MBI_SSL&display=touch&response_type=token&locale=en",
+	XBL:           "https://user.auth.xboxlive.com/user/authenticate",
+	XSTS:          "https://xsts.auth.xboxlive.com/xsts/authorize",
+	LoginWithXbox: "https://api.minecraftservices.com/authentication/login_with_xbox",
+	DeviceCode:    "https://login.microsoftonline.com/consumers/oauth2/v2.0/devicecode",
+	DeviceToken:   "https://login.microsoftonline.com/consumers/oauth2/v2.0/token",
+}
+
+// LoginConfig is shared by every client of the login chain
+type LoginConfig struct {
+	Timeout   time.Duration // per request, 0 for none
+	Endpoints LoginEndpoints
+	RootCAs   *x509.CertPool                           // trusted certificate authorities, the system ones if nil
+	Insecure  bool                                     // skip tls verification, only for debugging against a mock server
+	Warn      func(format string, args ...interface{}) // gets the insecure warning once per proxy, nil to drop it
+}
+
+const DefaultLoginTimeout = time.Second * 30
+
+// Login configures MicrosoftAuthenticate and the device code flow, set it before logging accounts in
+var Login = LoginConfig{
+	Timeout:   DefaultLoginTimeout,
+	Endpoints: DefaultLoginEndpoints,
+}
+
+// LoadCABundle reads the pem certificates at path on top of the system ones, for Login.RootCAs
+func LoadCABundle(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// proxies the insecure warning went out for, keyed by Proxy.String
+var insecureWarned sync.Map
+
+// loginClient builds a client for one login through p, direct if p is nil, with a fresh cookie jar.
+// onRedirect sees every redirect the client follows and may be nil.
+func loginClient(p *proxy.Proxy, onRedirect func(req *http.Request)) (*http.Client, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if Login.Insecure && Login.Warn != nil {
+		if _, warned := insecureWarned.LoadOrStore(p.String(), true); !warned {
+			Login.Warn("INSECURE: logging in via %v without verifying tls certificates", p.Redacted())
+		}
+	}
+
+	tr := p.Transport()
+	tr.TLSClientConfig = &tls.Config{
+		Renegotiation:      tls.RenegotiateOnceAsClient,
+		RootCAs:            Login.RootCAs,
+		InsecureSkipVerify: Login.Insecure,
+	}
+
+	client := &http.Client{
+		Jar:       jar,
+		Transport: tr,
+		Timeout:   Login.Timeout,
+	}
+	if onRedirect != nil {
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			onRedirect(req)
+			return nil
+		}
+	}
+	return client, nil
+}
+
+// xboxLogin takes a microsoft rps ticket through xbl, xsts and login_with_xbox and returns the minecraft bearer
+func xboxLogin(client *http.Client, rpsTicket string) (string, error) {
+	data := xBLSignInBody{
+		Properties: struct {
+			Authmethod string "json:\"AuthMethod\""
+			Sitename   string "json:\"SiteName\""
+			Rpsticket  string "json:\"RpsTicket\""
+		}{
+			Authmethod: "RPS",
+			Sitename:   "user.auth.xboxlive.com",
+			Rpsticket:  rpsTicket,
+		},
+		Relyingparty: "http://auth.xboxlive.com",
+		Tokentype:    "JWT",
+	}
+
+	encodedBody, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest("POST", Login.Endpoints.XBL, bytes.NewReader(encodedBody))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("x-xbl-contract-version", "1")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	respBodyBytes, err := io.ReadAll(resp.Body)
+	if resp.StatusCode == 400 {
+		return "", errors.New("invalid Rpsticket field probably")
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	var respBody XBLSignInResp
+
+	json.Unmarshal(respBodyBytes, &respBody)
+
+	if len(respBody.Displayclaims.Xui) == 0 {
+		return "", fmt.Errorf("xbl authenticate failed: %v", resp.Status)
+	}
+
+	uhs := respBody.Displayclaims.Xui[0].Uhs
+	XBLToken := respBody.Token
+
+	xstsBody := xSTSPostBody{
+		Properties: struct {
+			Sandboxid  string   "json:\"SandboxId\""
+			Usertokens []string "json:\"UserTokens\""
+		}{
+			Sandboxid: "RETAIL",
+			Usertokens: []string{
+				XBLToken,
+			},
+		},
+		Relyingparty: "rp://api.minecraftservices.com/",
+		Tokentype:    "JWT",
+	}
+
+	encodedXstsBody, err := json.Marshal(xstsBody)
+	if err != nil {
+		return "", err
+	}
+	req, err = http.NewRequest("POST", Login.Endpoints.XSTS, bytes.NewReader(encodedXstsBody))
+	if err != nil {
+		return "", err
+	}
+
+	resp, err = client.Do(req)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	respBodyBytes, err = io.ReadAll(resp.Body)
+
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode == 401 {
+		var authorizeXstsFail xSTSAuthorizeResponseFail
+		json.Unmarshal(respBodyBytes, &authorizeXstsFail)
+		switch authorizeXstsFail.Xerr {
+		case 2148916238:
+			return "", ErrChildAccount
+		case 2148916233:
+			return "", ErrNoXbox
+		default:
+			return "", fmt.Errorf("got error code %v when trying to authorize XSTS token", authorizeXstsFail.Xerr)
+		}
+	}
+
+	var xstsAuthorizeResp xSTSAuthorizeResponse
+	json.Unmarshal(respBodyBytes, &xstsAuthorizeResp)
+
+	xstsToken := xstsAuthorizeResp.Token
+
+	mojangBearerBody := msGetMojangbearerBody{
+		Identitytoken:       "XBL3.0 x=" + uhs + ";" + xstsToken,
+		Ensurelegacyenabled: true,
+	}
+
+	mojangBearerBodyEncoded, err := json.Marshal(mojangBearerBody)
+
+	if err != nil {
+		return "", err
+	}
+
+	req, err = http.NewRequest("POST", Login.Endpoints.LoginWithXbox, bytes.NewReader(mojangBearerBodyEncoded))
+
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err = client.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	mcBearerResponseBytes, err := io.ReadAll(resp.Body)
+
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("login_with_xbox failed: %v", resp.Status)
+	}
+
+	var mcBearerResp msGetMojangBearerResponse
+
+	json.Unmarshal(mcBearerResponseBytes, &mcBearerResp)
+
+	return mcBearerResp.AccessToken, nil
+}
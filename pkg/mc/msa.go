@@ -2,17 +2,24 @@ package mc
 
 import (
 	"bytes"
-	"crypto/tls"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"net/http/cookiejar"
 	"net/url"
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/Kqzz/MCsniperGO/pkg/proxy"
+)
+
+// login failures that retrying won't fix
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrTwoFactor          = errors.New("2fa is enabled, which is not supported now")
+	ErrChildAccount       = errors.New("microsoft account belongs to someone under 18! add to family for this to work")
+	ErrNoXbox             = errors.New("you have no xbox account! Sign up for one to continue")
 )
 
 type xBLSignInBody struct {
@@ -78,48 +85,25 @@ type msGetMojangBearerResponse struct {
 	Foci         string `json:"foci"`
 }
 
-func (account *MCaccount) MicrosoftAuthenticate(proxy string) error {
+func (account *MCaccount) MicrosoftAuthenticate(p *proxy.Proxy) error {
 
 	if account.Password == "code" {
-		account.OauthFlow()
-		return nil
+		return account.OauthFlow(p)
 	}
 
-	jar, err := cookiejar.New(nil)
-	if err != nil {
-		return err
-	}
-
-	proxyUrl, err := url.Parse(proxy)
+	var redirect string
+	client, err := loginClient(p, func(req *http.Request) {
+		redirect = req.URL.String()
+	})
 	if err != nil {
 		return err
 	}
 
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			Renegotiation:      tls.RenegotiateOnceAsClient,
-			InsecureSkipVerify: true,
-		},
-	}
-
-	if proxy != "" {
-		tr.Proxy = http.ProxyURL(proxyUrl)
-	}
-
-	var redirect string
-	client := &http.Client{
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			redirect = req.URL.String()
-			return nil
-		},
-		Jar:       jar,
-		Transport: tr,
-	}
 	// Grab value and urlpost
 	valRegex := regexp.MustCompile(`value="(.+?)"`)
 	urlPostRegex := regexp.MustCompile(`urlPost:'(.+?)'`)
 
-	resp, err := client.Get("https://login.live.com/oauth20_authorize.srf?client_id=000000004C12AE6F&redirect_uri=https://login.live.com/oauth20_desktop.srf&scope=service::user.auth.xboxlive.com::MBI_SSL&display=touch&response_type=token&locale=en")
+	resp, err := client.Get(Login.Endpoints.Authorize)
 
 	if err != nil {
 		return err
@@ -159,7 +143,7 @@ func (account *MCaccount) MicrosoftAuthenticate(proxy string) error {
 	defer resp.Body.Close()
 
 	if resp.Request.URL.String() == urlPost && strings.Contains(resp.Request.URL.String(), "access_token") {
-		return errors.New("invalid credentials, no access_token")
+		return fmt.Errorf("%w, no access_token", ErrInvalidCredentials)
 	}
 
 	respBytes, err = io.ReadAll(resp.Body)
@@ -171,15 +155,15 @@ func (account *MCaccount) MicrosoftAuthenticate(proxy string) error {
 	respStr := string(respBytes)
 
 	if strings.Contains(respStr, "Sign in to") {
-		return errors.New("invalid credentials, sign in to")
+		return fmt.Errorf("%w, sign in to", ErrInvalidCredentials)
 	}
 
 	if strings.Contains(respStr, "Help us protect your account") {
-		return errors.New("2fa is enabled, which is not supported now")
+		return ErrTwoFactor
 	}
 
 	if !strings.Contains(redirect, "access_token") || redirect == urlPost {
-		return errors.New("invalid credentials, no access_token in redirect")
+		return fmt.Errorf("%w, no access_token in redirect", ErrInvalidCredentials)
 	}
 
 	params := strings.Split(redirect, "#")[1]
@@ -192,154 +176,12 @@ func (account *MCaccount) MicrosoftAuthenticate(proxy string) error {
 		loginData[itemSplit[0]] = v
 	}
 
-	data := xBLSignInBody{
-		Properties: struct {
-			Authmethod string "json:\"AuthMethod\""
-			Sitename   string "json:\"SiteName\""
-			Rpsticket  string "json:\"RpsTicket\""
-		}{
-			Authmethod: "RPS",
-			Sitename:   "user.auth.xboxlive.com",
-			Rpsticket:  loginData["access_token"],
-		},
-		Relyingparty: "http://auth.xboxlive.com",
-		Tokentype:    "JWT",
-	}
-
-	encodedBody, err := json.Marshal(data)
-	if err != nil {
-		return err
-	}
-	req, err = http.NewRequest("POST", "https://user.auth.xboxlive.com/user/authenticate", bytes.NewReader(encodedBody))
-	if err != nil {
-		return err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("x-xbl-contract-version", "1")
-
-	resp, err = client.Do(req)
-	if err != nil {
-		return err
-	}
-
-	defer resp.Body.Close()
-
-	respBodyBytes, err := io.ReadAll(resp.Body)
-	if resp.StatusCode == 400 {
-		return errors.New("invalid Rpsticket field probably")
-	}
-
-	if err != nil {
-		return err
-	}
-
-	var respBody XBLSignInResp
-
-	json.Unmarshal(respBodyBytes, &respBody)
-
-	uhs := respBody.Displayclaims.Xui[0].Uhs
-	XBLToken := respBody.Token
-
-	xstsBody := xSTSPostBody{
-		Properties: struct {
-			Sandboxid  string   "json:\"SandboxId\""
-			Usertokens []string "json:\"UserTokens\""
-		}{
-			Sandboxid: "RETAIL",
-			Usertokens: []string{
-				XBLToken,
-			},
-		},
-		Relyingparty: "rp://api.minecraftservices.com/",
-		Tokentype:    "JWT",
-	}
-
-	encodedXstsBody, err := json.Marshal(xstsBody)
-	if err != nil {
-		return err
-	}
-	req, err = http.NewRequest("POST", "https://xsts.auth.xboxlive.com/xsts/authorize", bytes.NewReader(encodedXstsBody))
-	if err != nil {
-		return err
-	}
-
-	resp, err = client.Do(req)
-
-	if err != nil {
-		return err
-	}
-
-	respBodyBytes, err = io.ReadAll(resp.Body)
-
-	if err != nil {
-		return err
-	}
-
-	if resp.StatusCode == 401 {
-		var authorizeXstsFail xSTSAuthorizeResponseFail
-		json.Unmarshal(respBodyBytes, &authorizeXstsFail)
-		switch authorizeXstsFail.Xerr {
-		case 2148916238:
-			{
-				return errors.New("microsoft account belongs to someone under 18! add to family for this to work")
-			}
-		case 2148916233:
-			{
-				return errors.New("you have no xbox account! Sign up for one to continue")
-			}
-		default:
-			{
-				return fmt.Errorf("got error code %v when trying to authorize XSTS token", authorizeXstsFail.Xerr)
-			}
-		}
-	}
-
-	var xstsAuthorizeResp xSTSAuthorizeResponse
-	json.Unmarshal(respBodyBytes, &xstsAuthorizeResp)
-
-	xstsToken := xstsAuthorizeResp.Token
-
-	mojangBearerBody := msGetMojangbearerBody{
-		Identitytoken:       "XBL3.0 x=" + uhs + ";" + xstsToken,
-		Ensurelegacyenabled: true,
-	}
-
-	mojangBearerBodyEncoded, err := json.Marshal(mojangBearerBody)
-
+	bearer, err := xboxLogin(client, loginData["access_token"])
 	if err != nil {
 		return err
 	}
 
-	req, err = http.NewRequest("POST", "https://api.minecraftservices.com/authentication/login_with_xbox", bytes.NewReader(mojangBearerBodyEncoded))
-
-	req.Header.Set("Content-Type", "application/json")
-
-	if err != nil {
-		return err
-	}
-
-	resp, err = client.Do(req)
-	if err != nil {
-		return err
-	}
-
-	mcBearerResponseBytes, err := io.ReadAll(resp.Body)
-
-	if err != nil {
-		return err
-	}
-
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("login_with_xbox failed: %v", resp.Status)
-	}
-
-	var mcBearerResp msGetMojangBearerResponse
-
-	json.Unmarshal(mcBearerResponseBytes, &mcBearerResp)
-
-	account.Bearer = mcBearerResp.AccessToken
+	account.Bearer = bearer
 
 	return nil
 }
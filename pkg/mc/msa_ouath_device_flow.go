@@ -2,14 +2,14 @@ package mc
 
 import (
 	"bytes"
-	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"net/http/cookiejar"
 	"time"
+
+	"github.com/Kqzz/MCsniperGO/pkg/proxy"
 )
 
 /*
@@ -65,26 +65,15 @@ const client_id = "648b1790-3c45-4745-bd7b-d9e828433655"
 
 // types in msa.go are used here as well.
 
-func (account *MCaccount) OauthFlow() error {
-	jar, err := cookiejar.New(nil)
+func (account *MCaccount) OauthFlow(p *proxy.Proxy) error {
+	client, err := loginClient(p, nil)
 	if err != nil {
 		return err
 	}
 
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			Renegotiation:      tls.RenegotiateOnceAsClient,
-			InsecureSkipVerify: true},
-	}
-
-	client := &http.Client{
-		Jar:       jar,
-		Transport: tr,
-	}
-
 	reqParams := fmt.Sprintf("client_id=%s&scope=XboxLive.signin", client_id)
 
-	req, _ := http.NewRequest("POST", "https://login.microsoftonline.com/consumers/oauth2/v2.0/devicecode", bytes.NewBuffer([]byte(reqParams)))
+	req, _ := http.NewRequest("POST", Login.Endpoints.DeviceCode, bytes.NewBuffer([]byte(reqParams)))
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
@@ -109,197 +98,28 @@ func (account *MCaccount) OauthFlow() error {
 	}
 	fmt.Printf("[*] %v\n", respObj.Message)
 
-	return pollEndpoint(account, respObj.DeviceCode, respObj.Interval)
+	return pollEndpoint(account, client, respObj.DeviceCode, respObj.Interval)
 }
 
-func authWithToken(account *MCaccount, access_token_from_ms string) error {
-	jar, err := cookiejar.New(nil)
-	if err != nil {
-		return err
-	}
-
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			Renegotiation:      tls.RenegotiateOnceAsClient,
-			InsecureSkipVerify: true,
-		},
-	}
-
-	client := &http.Client{
-		Jar:       jar,
-		Transport: tr,
-	}
-	data := xBLSignInBody{
-		Properties: struct {
-			Authmethod string "json:\"AuthMethod\""
-			Sitename   string "json:\"SiteName\""
-			Rpsticket  string "json:\"RpsTicket\""
-		}{
-			Authmethod: "RPS",
-			Sitename:   "user.auth.xboxlive.com",
-			Rpsticket:  "d=" + access_token_from_ms,
-		},
-		Relyingparty: "http://auth.xboxlive.com",
-		Tokentype:    "JWT",
-	}
-
-	encodedBody, err := json.Marshal(data)
-	if err != nil {
-		return err
-	}
-	req, err := http.NewRequest("POST", "https://user.auth.xboxlive.com/user/authenticate", bytes.NewReader(encodedBody))
-	if err != nil {
-		return err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("x-xbl-contract-version", "1")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-
-	defer resp.Body.Close()
-
-	respBodyBytes, err := io.ReadAll(resp.Body)
-	if resp.StatusCode == 400 {
-		return errors.New("invalid Rpsticket field probably")
-	}
-
-	if err != nil {
-		return err
-	}
-
-	var respBody XBLSignInResp
-
-	json.Unmarshal(respBodyBytes, &respBody)
-
-	uhs := respBody.Displayclaims.Xui[0].Uhs
-	XBLToken := respBody.Token
-
-	xstsBody := xSTSPostBody{
-		Properties: struct {
-			Sandboxid  string   "json:\"SandboxId\""
-			Usertokens []string "json:\"UserTokens\""
-		}{
-			Sandboxid: "RETAIL",
-			Usertokens: []string{
-				XBLToken,
-			},
-		},
-		Relyingparty: "rp://api.minecraftservices.com/",
-		Tokentype:    "JWT",
-	}
-
-	encodedXstsBody, err := json.Marshal(xstsBody)
-	if err != nil {
-		return err
-	}
-	req, err = http.NewRequest("POST", "https://xsts.auth.xboxlive.com/xsts/authorize", bytes.NewReader(encodedXstsBody))
-	if err != nil {
-		return err
-	}
-
-	resp, err = client.Do(req)
-
+func authWithToken(account *MCaccount, client *http.Client, access_token_from_ms string) error {
+	bearer, err := xboxLogin(client, "d="+access_token_from_ms)
 	if err != nil {
 		return err
 	}
 
-	respBodyBytes, err = io.ReadAll(resp.Body)
-
-	if err != nil {
-		return err
-	}
-
-	if resp.StatusCode == 401 {
-		var authorizeXstsFail xSTSAuthorizeResponseFail
-		json.Unmarshal(respBodyBytes, &authorizeXstsFail)
-		switch authorizeXstsFail.Xerr {
-		case 2148916238:
-			{
-				return errors.New("microsoft account belongs to someone under 18! add to family for this to work")
-			}
-		case 2148916233:
-			{
-				return errors.New("you have no xbox account! Sign up for one to continue")
-			}
-		default:
-			{
-				return fmt.Errorf("got error code %v when trying to authorize XSTS token", authorizeXstsFail.Xerr)
-			}
-		}
-	}
-
-	var xstsAuthorizeResp xSTSAuthorizeResponse
-	json.Unmarshal(respBodyBytes, &xstsAuthorizeResp)
-
-	xstsToken := xstsAuthorizeResp.Token
-
-	mojangBearerBody := msGetMojangbearerBody{
-		Identitytoken:       "XBL3.0 x=" + uhs + ";" + xstsToken,
-		Ensurelegacyenabled: true,
-	}
-
-	mojangBearerBodyEncoded, err := json.Marshal(mojangBearerBody)
-
-	if err != nil {
-		return err
-	}
-
-	req, err = http.NewRequest("POST", "https://api.minecraftservices.com/authentication/login_with_xbox", bytes.NewReader(mojangBearerBodyEncoded))
-
-	req.Header.Set("Content-Type", "application/json")
-
-	if err != nil {
-		return err
-	}
-
-	resp, err = client.Do(req)
-	if err != nil {
-		return err
-	}
-
-	mcBearerResponseBytes, err := io.ReadAll(resp.Body)
-
-	if err != nil {
-		return err
-	}
-
-	var mcBearerResp msGetMojangBearerResponse
-
-	json.Unmarshal(mcBearerResponseBytes, &mcBearerResp)
-
-	account.Bearer = mcBearerResp.AccessToken
+	account.Bearer = bearer
 
 	return nil
 }
 
-func pollEndpoint(account *MCaccount, device_code string, interval int) error {
+func pollEndpoint(account *MCaccount, client *http.Client, device_code string, interval int) error {
 
 	sleepDuration := time.Second * time.Duration(interval)
-	jar, err := cookiejar.New(nil)
-	if err != nil {
-		return err
-	}
-
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			Renegotiation:      tls.RenegotiateOnceAsClient,
-			InsecureSkipVerify: true},
-	}
-
-	client := &http.Client{
-		Jar:       jar,
-		Transport: tr,
-	}
 
 	reqParams := fmt.Sprintf("grant_type=urn:ietf:params:oauth:grant-type:device_code&device_code=%s&client_id=%s", device_code, client_id)
 	for {
 		time.Sleep(sleepDuration)
-		req, err := http.NewRequest("POST", "https://login.microsoftonline.com/consumers/oauth2/v2.0/token", bytes.NewBuffer([]byte(reqParams)))
+		req, err := http.NewRequest("POST", Login.Endpoints.DeviceToken, bytes.NewBuffer([]byte(reqParams)))
 		if err != nil {
 			return err
 		}
@@ -334,7 +154,7 @@ func pollEndpoint(account *MCaccount, device_code string, interval int) error {
 			if err != nil {
 				return err
 			}
-			return authWithToken(account, r.AccessToken)
+			return authWithToken(account, client, r.AccessToken)
 		} else {
 			return errors.New("status code response not 200 or 400")
 		}
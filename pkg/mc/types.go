@@ -3,6 +3,8 @@ package mc
 import (
 	"time"
 
+	"github.com/Kqzz/MCsniperGO/pkg/proxy"
+
 	"github.com/valyala/fasthttp"
 )
 
@@ -43,6 +45,10 @@ type MCaccount struct {
 	Username       string
 	FastHttpClient *fasthttp.Client // client is used for all requests except create auth, profile create, and name change
 	Type           AccType
+	Group          string       // named group the account belongs to, e.g. "team-a"
+	Tags           []string     // free-form labels, e.g. "reserve" or "fresh-gc"
+	Label          string       // display name from the accounts file
+	Proxy          *proxy.Proxy // proxy the account is bound to, nil if it isn't
 }
 
 /// HTTP RESPONSE BODIES ///
@@ -0,0 +1,182 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Kqzz/MCsniperGO/claimer"
+	"github.com/Kqzz/MCsniperGO/log"
+)
+
+const DefaultConfigPath = "webhooks.json"
+
+type Format string
+
+const (
+	Discord Format = "discord"
+	Slack   Format = "slack"
+	JSON    Format = "json"
+)
+
+// events sent when a webhook doesn't pick its own. a 429 or 401 is only reported the first time per snipe.
+var DefaultEvents = []claimer.EventType{
+	claimer.EventAuthed,
+	claimer.EventStarted,
+	claimer.EventCooldown,
+	claimer.EventQuarantine,
+	claimer.EventClaimed,
+	claimer.EventUnconfirmed,
+	claimer.EventEnded,
+	claimer.EventError,
+	claimer.EventAvailable,
+}
+
+type Webhook struct {
+	URL    string              `json:"url"`
+	Format Format              `json:"format"`           // payload template, json if empty
+	Events []claimer.EventType `json:"events,omitempty"` // DefaultEvents if empty
+}
+
+func (w Webhook) wants(t claimer.EventType) bool {
+	events := w.Events
+	if len(events) == 0 {
+		events = DefaultEvents
+	}
+	for _, e := range events {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Config is the webhook setup shared by the cli and the web server
+type Config struct {
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+// LoadConfig reads the config at path, an empty one if the file doesn't exist
+func LoadConfig(path string) (Config, error) {
+	config := Config{Webhooks: []Webhook{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return config, err
+	}
+
+	err = json.Unmarshal(data, &config)
+	if err != nil {
+		return config, err
+	}
+	return config, config.Validate()
+}
+
+func (c Config) Validate() error {
+	for i, hook := range c.Webhooks {
+		if hook.URL == "" {
+			return fmt.Errorf("webhook #%d has no url", i+1)
+		}
+		switch hook.Format {
+		case "", Discord, Slack, JSON:
+		default:
+			return fmt.Errorf("webhook #%d has unknown format %q", i+1, hook.Format)
+		}
+	}
+	return nil
+}
+
+func (c Config) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Notifier posts claimer events to the configured webhooks
+type Notifier struct {
+	mu     sync.Mutex
+	config Config
+	seen   map[claimer.EventType]bool // once-per-snipe events already sent
+	client *http.Client
+}
+
+func NewNotifier(config Config) *Notifier {
+	return &Notifier{
+		config: config,
+		seen:   map[claimer.EventType]bool{},
+		client: &http.Client{Timeout: time.Second * 10},
+	}
+}
+
+// Attach subscribes the notifier to claimer events
+func (n *Notifier) Attach() {
+	claimer.Subscribe(n.handle)
+}
+
+func (n *Notifier) Config() Config {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.config
+}
+
+func (n *Notifier) SetConfig(config Config) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.config = config
+}
+
+func (n *Notifier) handle(e claimer.Event) {
+	n.mu.Lock()
+	switch e.Type {
+	case claimer.EventAuthed:
+		n.seen = map[claimer.EventType]bool{}
+	case claimer.EventCooldown, claimer.EventQuarantine:
+		if n.seen[e.Type] {
+			n.mu.Unlock()
+			return
+		}
+		n.seen[e.Type] = true
+	}
+	hooks := n.config.Webhooks
+	n.mu.Unlock()
+
+	for _, hook := range hooks {
+		if !hook.wants(e.Type) {
+			continue
+		}
+		go func(hook Webhook) {
+			if err := n.Send(hook, e); err != nil {
+				log.Log("err", "failed to send %v webhook: %v", e.Type, err)
+			}
+		}(hook)
+	}
+}
+
+// Send posts e to hook in its format
+func (n *Notifier) Send(hook Webhook, e claimer.Event) error {
+	body, err := json.Marshal(Payload(hook.Format, e))
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.client.Post(hook.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook answered %v", resp.Status)
+	}
+	return nil
+}
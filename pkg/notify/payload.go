@@ -0,0 +1,138 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Kqzz/MCsniperGO/claimer"
+)
+
+// embed colors for discord
+const (
+	colorGreen  = 0x2ecc71
+	colorBlue   = 0x3498db
+	colorOrange = 0xe67e22
+	colorRed    = 0xe74c3c
+)
+
+var titles = map[claimer.EventType]string{
+	claimer.EventAuthed:      "Accounts authenticated",
+	claimer.EventStarted:     "Snipe started",
+	claimer.EventCooldown:    "Ratelimited (429)",
+	claimer.EventQuarantine:  "Unauthorized (401)",
+	claimer.EventClaimed:     "Name claimed",
+	claimer.EventUnconfirmed: "Claim didn't hold up",
+	claimer.EventTaken:       "Name taken by someone else",
+	claimer.EventEnded:       "Snipe ended without a claim",
+	claimer.EventError:       "Snipe failed",
+	claimer.EventAvailable:   "Watched name available",
+}
+
+func title(e claimer.Event) string {
+	if t, ok := titles[e.Type]; ok {
+		return t
+	}
+	return string(e.Type)
+}
+
+func color(e claimer.Event) int {
+	switch e.Type {
+	case claimer.EventClaimed, claimer.EventAvailable:
+		return colorGreen
+	case claimer.EventError, claimer.EventEnded, claimer.EventTaken:
+		return colorRed
+	case claimer.EventCooldown, claimer.EventQuarantine, claimer.EventUnconfirmed:
+		return colorOrange
+	}
+	return colorBlue
+}
+
+type field struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// fields lists the parts of e that are set, in a fixed order
+func fields(e claimer.Event) []field {
+	all := []field{
+		{"Name", e.Name},
+		{"Account type", string(e.AccType)},
+		{"Account", e.Account},
+		{"Proxy", e.Proxy},
+	}
+	if e.AccNum > 0 {
+		all = append(all, field{"Account #", fmt.Sprint(e.AccNum)})
+	}
+	if e.Count > 0 {
+		all = append(all, field{"Count", fmt.Sprint(e.Count)})
+	}
+
+	set := []field{}
+	for _, f := range all {
+		if f.Value != "" {
+			set = append(set, f)
+		}
+	}
+	return set
+}
+
+// Payload renders e as the body a webhook of the given format expects
+func Payload(format Format, e claimer.Event) interface{} {
+	e.Message = strings.TrimSpace(e.Message)
+
+	switch format {
+	case Discord:
+		return discordPayload(e)
+	case Slack:
+		return slackPayload(e)
+	}
+	return jsonPayload(e)
+}
+
+func discordPayload(e claimer.Event) interface{} {
+	type embedField struct {
+		Name   string `json:"name"`
+		Value  string `json:"value"`
+		Inline bool   `json:"inline"`
+	}
+
+	embedFields := []embedField{}
+	for _, f := range fields(e) {
+		embedFields = append(embedFields, embedField{Name: f.Name, Value: f.Value, Inline: true})
+	}
+
+	return map[string]interface{}{
+		"username": "MCsniperGO",
+		"embeds": []map[string]interface{}{{
+			"title":       title(e),
+			"description": e.Message,
+			"color":       color(e),
+			"timestamp":   e.Time.UTC().Format(time.RFC3339),
+			"fields":      embedFields,
+		}},
+	}
+}
+
+func slackPayload(e claimer.Event) interface{} {
+	lines := []string{fmt.Sprintf("*%v*", title(e)), e.Message}
+	for _, f := range fields(e) {
+		lines = append(lines, fmt.Sprintf("• %v: `%v`", f.Name, f.Value))
+	}
+	return map[string]string{"text": strings.Join(lines, "\n")}
+}
+
+func jsonPayload(e claimer.Event) interface{} {
+	return map[string]interface{}{
+		"event":   e.Type,
+		"title":   title(e),
+		"time":    e.Time.UTC().Format(time.RFC3339Nano),
+		"name":    e.Name,
+		"accType": e.AccType,
+		"accNum":  e.AccNum,
+		"account": e.Account,
+		"proxy":   e.Proxy,
+		"count":   e.Count,
+		"message": e.Message,
+	}
+}
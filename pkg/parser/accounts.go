@@ -0,0 +1,321 @@
+package parser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Kqzz/MCsniperGO/pkg/mc"
+	"github.com/Kqzz/MCsniperGO/pkg/proxy"
+)
+
+const DefaultAccountsPath = "accounts.json"
+
+// AccountRecord is one account in the structured accounts file
+type AccountRecord struct {
+	Type         mc.AccType `json:"type"`
+	Email        string     `json:"email,omitempty"`
+	Password     string     `json:"password,omitempty"`
+	Bearer       string     `json:"bearer,omitempty"`
+	RefreshToken string     `json:"refreshToken,omitempty"`
+	Proxy        string     `json:"proxy,omitempty"` // proxy the account is bound to, in any format proxies.txt takes
+	Group        string     `json:"group,omitempty"`
+	Tags         []string   `json:"tags,omitempty"`
+	Label        string     `json:"label,omitempty"`
+	Enabled      *bool      `json:"enabled,omitempty"` // enabled unless set to false
+	Notes        string     `json:"notes,omitempty"`
+}
+
+type AccountsFile struct {
+	Accounts []AccountRecord `json:"accounts"`
+}
+
+func (r AccountRecord) IsEnabled() bool {
+	return r.Enabled == nil || *r.Enabled
+}
+
+// Account turns the record into an account ready to authenticate
+func (r AccountRecord) Account() (*mc.MCaccount, error) {
+	switch r.Type {
+	case mc.Ms, mc.MsPr, mc.MsGp:
+	default:
+		return nil, fmt.Errorf("unknown account type %q", r.Type)
+	}
+
+	if r.Bearer == "" && (r.Email == "" || r.Password == "") {
+		return nil, errors.New("needs a bearer or an email and password")
+	}
+
+	acc := &mc.MCaccount{
+		Type:         r.Type,
+		Email:        r.Email,
+		Password:     r.Password,
+		Bearer:       r.Bearer,
+		RefreshToken: r.RefreshToken,
+		Group:        r.Group,
+		Tags:         r.Tags,
+		Label:        r.Label,
+	}
+
+	if r.Proxy != "" {
+		p, err := proxy.Parse(r.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy: %v", err)
+		}
+		acc.Proxy = p
+	}
+
+	acc.DefaultFastHttpHandler()
+	return acc, nil
+}
+
+// name identifies a record in errors without printing its password or bearer
+func (r AccountRecord) name() string {
+	switch {
+	case r.Label != "":
+		return r.Label
+	case r.Email != "":
+		return r.Email
+	case len(r.Bearer) > 8:
+		return "bearer ..." + r.Bearer[len(r.Bearer)-8:]
+	}
+	return "account"
+}
+
+// checkFormat turns away yaml paths, the accounts file is json only
+func checkFormat(path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return fmt.Errorf("%v: yaml accounts files aren't supported, use json", path)
+	}
+	return nil
+}
+
+// LoadAccountsFile reads every record in the accounts file at path
+func LoadAccountsFile(path string) ([]AccountRecord, error) {
+	if err := checkFormat(path); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file := AccountsFile{}
+	err = json.Unmarshal(data, &file)
+	if err != nil {
+		return nil, err
+	}
+	return file.Accounts, nil
+}
+
+func SaveAccountsFile(path string, records []AccountRecord) error {
+	if err := checkFormat(path); err != nil {
+		return err
+	}
+	if records == nil {
+		records = []AccountRecord{}
+	}
+
+	data, err := json.MarshalIndent(AccountsFile{Accounts: records}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// RecordAccounts builds accounts from the enabled records, skipping and reporting the invalid ones
+func RecordAccounts(records []AccountRecord) ([]*mc.MCaccount, []error) {
+	accounts, errs := []*mc.MCaccount{}, []error{}
+	for i, r := range records {
+		if !r.IsEnabled() {
+			continue
+		}
+
+		acc, err := r.Account()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("account #%d (%v): %v", i+1, r.name(), err))
+			continue
+		}
+		accounts = append(accounts, acc)
+	}
+	return accounts, errs
+}
+
+// ParseRecords reads txt account lines into records of accType
+func ParseRecords(lines []string, accType mc.AccType) ([]AccountRecord, []error) {
+	records, errs := []AccountRecord{}, []error{}
+	for i, l := range lines {
+		r, ok, err := parseLine(l, accType)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %v: %v", i, err))
+		}
+		if ok {
+			records = append(records, r)
+		}
+	}
+	return records, errs
+}
+
+// ImportTxt merges the records of the txt files into existing, leaving accounts that are already in it alone
+func ImportTxt(existing []AccountRecord, imported ...[]AccountRecord) (merged []AccountRecord, added int) {
+	merged = append([]AccountRecord{}, existing...)
+
+	seen := map[string]bool{}
+	for _, r := range merged {
+		seen[recordKey(r)] = true
+	}
+
+	for _, records := range imported {
+		for _, r := range records {
+			if seen[recordKey(r)] {
+				continue
+			}
+			seen[recordKey(r)] = true
+			merged = append(merged, r)
+			added++
+		}
+	}
+	return merged, added
+}
+
+func recordKey(r AccountRecord) string {
+	if r.Email != "" {
+		return string(r.Type) + ":" + strings.ToLower(r.Email)
+	}
+	return string(r.Type) + ":" + r.Bearer
+}
+
+// ExportTxt writes records back as txt lines, split by type. labels, notes, refresh tokens and
+// the bearers of email:password accounts have no place in a txt file and are left out.
+func ExportTxt(records []AccountRecord) map[mc.AccType][]string {
+	lines := map[mc.AccType][]string{}
+	for _, r := range records {
+		lines[r.Type] = append(lines[r.Type], FormatLine(r))
+	}
+	return lines
+}
+
+// FormatLine renders a record the way ParseRecords reads it
+func FormatLine(r AccountRecord) string {
+	line := r.Bearer
+	meta := []string{}
+	if r.Email != "" && r.Password != "" {
+		line = r.Email + ":" + r.Password
+		if r.Proxy != "" {
+			line += ":" + r.Proxy
+		}
+	} else {
+		if r.Email != "" {
+			meta = append(meta, "email="+r.Email)
+		}
+		if r.Proxy != "" {
+			meta = append(meta, "proxy="+r.Proxy)
+		}
+	}
+
+	if r.Group != "" {
+		meta = append(meta, "group="+r.Group)
+	}
+	if len(r.Tags) > 0 {
+		meta = append(meta, "tags="+strings.Join(r.Tags, ","))
+	}
+	if !r.IsEnabled() {
+		meta = append(meta, "enabled=false")
+	}
+	if len(meta) > 0 {
+		line += " # " + strings.Join(meta, " ")
+	}
+	return line
+}
+
+// GiftCodeLine is an account read from an email:password:giftcode line, optionally followed by :proxy
+type GiftCodeLine struct {
+	Record AccountRecord // a GC account, bound to the proxy if the line had one
+	Code   string
+}
+
+// ParseGiftCodes reads email:password:giftcode[:proxy] lines
+func ParseGiftCodes(lines []string) ([]GiftCodeLine, []error) {
+	codes, errs := []GiftCodeLine{}, []error{}
+	for i, l := range lines {
+		r, ok, err := parseLine(l, mc.MsPr)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %v: %v", i, err))
+		}
+		if !ok {
+			continue
+		}
+
+		// parseLine takes everything after the password as the proxy
+		rest := strings.SplitN(r.Proxy, ":", 2)
+		if r.Email == "" || rest[0] == "" {
+			errs = append(errs, fmt.Errorf("line %v: expected email:password:giftcode", i))
+			continue
+		}
+
+		r.Proxy = ""
+		if len(rest) == 2 {
+			r.Proxy = rest[1]
+		}
+		codes = append(codes, GiftCodeLine{Record: r, Code: rest[0]})
+	}
+	return codes, errs
+}
+
+// AddAccounts saves records to the accounts file at accountsPath if there is one, and to the end of txtPath otherwise,
+// which should be the txt file of the records' type. accounts that are already there are left alone.
+func AddAccounts(accountsPath string, txtPath string, records []AccountRecord) (added int, err error) {
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	existing, err := LoadAccountsFile(accountsPath)
+	if err == nil {
+		merged, added := ImportTxt(existing, records)
+		return added, SaveAccountsFile(accountsPath, merged)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+
+	lines, _ := ReadLines(txtPath)
+	current, _ := ParseRecords(lines, records[0].Type)
+	_, added = ImportTxt(current, records)
+	if added == 0 {
+		return 0, nil
+	}
+
+	seen := map[string]bool{}
+	for _, r := range current {
+		seen[recordKey(r)] = true
+	}
+
+	file, err := os.OpenFile(txtPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	// the last line may not have been ended
+	if raw, _ := os.ReadFile(txtPath); len(raw) > 0 && raw[len(raw)-1] != '\n' {
+		if _, err := file.WriteString("\n"); err != nil {
+			return 0, err
+		}
+	}
+
+	for _, r := range records {
+		if seen[recordKey(r)] {
+			continue
+		}
+		seen[recordKey(r)] = true
+		if _, err := file.WriteString(FormatLine(r) + "\n"); err != nil {
+			return 0, err
+		}
+	}
+	return added, nil
+}
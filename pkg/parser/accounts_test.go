@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Kqzz/MCsniperGO/pkg/mc"
+)
+
+// a bearer long enough, and shaped enough like a jwt, for parseLine to take it as one
+var testBearer = "eyJ" + strings.Repeat("a", 240)
+
+func TestRoundTrip(t *testing.T) {
+	off := false
+	records := []AccountRecord{
+		{Type: mc.Ms, Email: "a@example.com", Password: "pw"},
+		{Type: mc.Ms, Email: "b@example.com", Password: "pw", Proxy: "1.2.3.4:8080:user:pass", Group: "team-a", Tags: []string{"reserve", "fresh"}},
+		{Type: mc.Ms, Email: "c@example.com", Password: "pw", Enabled: &off},
+		{Type: mc.Ms, Bearer: testBearer},
+		{Type: mc.Ms, Bearer: testBearer + "b", Email: "d@example.com", Proxy: "socks5://5.6.7.8:1080", Group: "team-b", Enabled: &off},
+	}
+
+	lines := ExportTxt(records)[mc.Ms]
+	if len(lines) != len(records) {
+		t.Fatalf("ExportTxt wrote %d lines, want %d: %q", len(lines), len(records), lines)
+	}
+
+	got, errs := ParseRecords(lines, mc.Ms)
+	if len(errs) != 0 {
+		t.Fatalf("ParseRecords failed: %v", errs)
+	}
+	if !reflect.DeepEqual(got, records) {
+		t.Errorf("round trip changed the records\n got  %+v\n want %+v\nlines %q", got, records, lines)
+	}
+
+	for i, r := range records {
+		if line := FormatLine(got[i]); line != lines[i] {
+			t.Errorf("FormatLine(record %d) = %q, want %q", i, line, lines[i])
+		}
+		if got[i].IsEnabled() != r.IsEnabled() {
+			t.Errorf("record %d enabled = %v, want %v", i, got[i].IsEnabled(), r.IsEnabled())
+		}
+	}
+}
+
+func TestParseAccountsSkipsDisabled(t *testing.T) {
+	lines := []string{
+		"a@example.com:pw",
+		"b@example.com:pw # enabled=false",
+		"# c@example.com:pw",
+		"",
+	}
+
+	accounts, errs := ParseAccounts(lines, mc.Ms)
+	if len(errs) != 0 {
+		t.Fatalf("ParseAccounts failed: %v", errs)
+	}
+	if len(accounts) != 1 || accounts[0].Email != "a@example.com" {
+		t.Errorf("ParseAccounts = %v, want only a@example.com", accounts)
+	}
+}
+
+func TestImportTxt(t *testing.T) {
+	existing := []AccountRecord{
+		{Type: mc.Ms, Email: "a@example.com", Password: "old", Label: "main"},
+		{Type: mc.Ms, Bearer: testBearer},
+	}
+	imported := []AccountRecord{
+		{Type: mc.Ms, Email: "A@Example.com", Password: "new"},  // same account, other case
+		{Type: mc.MsGp, Email: "a@example.com", Password: "pw"}, // same email, other type
+		{Type: mc.Ms, Bearer: testBearer},
+		{Type: mc.Ms, Email: "b@example.com", Password: "pw"},
+		{Type: mc.Ms, Email: "b@example.com", Password: "again"}, // repeated within the import
+	}
+
+	merged, added := ImportTxt(existing, imported)
+	if added != 2 {
+		t.Errorf("ImportTxt added %d, want 2", added)
+	}
+
+	want := []AccountRecord{
+		existing[0],
+		existing[1],
+		imported[1],
+		imported[3],
+	}
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("ImportTxt merged\n %+v\nwant\n %+v", merged, want)
+	}
+}
+
+func TestParseAccountsBearerHasNoEmail(t *testing.T) {
+	accounts, errs := ParseAccounts([]string{testBearer}, mc.Ms)
+	if len(errs) != 0 {
+		t.Fatalf("ParseAccounts failed: %v", errs)
+	}
+	if len(accounts) != 1 || accounts[0].Email != "" {
+		t.Errorf("ParseAccounts = %+v, want one bearer account without an email", accounts)
+	}
+}
+
+func TestAccountsFileRejectsYAML(t *testing.T) {
+	for _, path := range []string{"accounts.yaml", "accounts.YML"} {
+		if _, err := LoadAccountsFile(path); err == nil || !strings.Contains(err.Error(), "yaml") {
+			t.Errorf("LoadAccountsFile(%q) = %v, want a yaml error", path, err)
+		}
+	}
+}
@@ -2,47 +2,102 @@ package parser
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/Kqzz/MCsniperGO/pkg/mc"
 )
 
-func ParseAccounts(accs []string, accType mc.AccType) ([]*mc.MCaccount, []error) {
-	parsed, errs := []*mc.MCaccount{}, []error{}
-	for i, l := range accs {
-
-		if len(l) > 0 && l[0] == '#' { // commented
-			continue
+// metadata after " #" on an account line, e.g. "email:pass # group=team-a tags=reserve,fresh-gc".
+// bearer lines carry their email and proxy here too, and disabled accounts enabled=false.
+func parseMeta(r *AccountRecord, meta string) error {
+	for _, field := range strings.Fields(meta) {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("invalid metadata %q, expected key=value", field)
 		}
 
-		if len(l) > 200 &&
-			!strings.Contains(l, ":") &&
-			strings.HasPrefix(l, "eyJ") { // bearer token
-			acc := &mc.MCaccount{Email: l[40:50], Type: accType, Bearer: l}
-			acc.DefaultFastHttpHandler()
-			parsed = append(parsed, acc)
-			continue
+		switch kv[0] {
+		case "group":
+			r.Group = kv[1]
+		case "tags":
+			for _, tag := range strings.Split(kv[1], ",") {
+				if tag != "" {
+					r.Tags = append(r.Tags, tag)
+				}
+			}
+		case "email":
+			r.Email = kv[1]
+		case "proxy":
+			r.Proxy = kv[1]
+		case "enabled":
+			enabled, err := strconv.ParseBool(kv[1])
+			if err != nil {
+				return fmt.Errorf("invalid enabled %q, expected true or false", kv[1])
+			}
+			r.Enabled = &enabled
+		default:
+			return fmt.Errorf("unknown metadata key %q", kv[0])
 		}
+	}
+	return nil
+}
 
-		s := strings.Split(l, ":")
+// parseLine reads one txt account line. ok is false for blank and commented lines and ones that can't be used,
+// an error with ok set means the account is usable but some of the line was ignored.
+func parseLine(l string, accType mc.AccType) (r AccountRecord, ok bool, err error) {
+	if len(strings.TrimSpace(l)) == 0 || l[0] == '#' { // commented
+		return r, false, nil
+	}
 
-		if len(s) == 0 {
-			continue
-		}
+	meta := ""
+	if at := strings.Index(l, " #"); at != -1 {
+		l, meta = strings.TrimSpace(l[:at]), l[at+2:]
+	}
+
+	r.Type = accType
 
-		acc := &mc.MCaccount{Type: accType}
+	if len(l) > 200 &&
+		!strings.Contains(l, ":") &&
+		strings.HasPrefix(l, "eyJ") { // bearer token
+		r.Bearer = l
+		return r, true, parseMeta(&r, meta)
+	}
 
-		if len(s) >= 2 {
-			acc.Email = s[0]
-			acc.Password = s[1]
-		} else {
-			errs = append(errs, fmt.Errorf("invalid split count on line %v", i))
+	s := strings.Split(l, ":")
+
+	if len(s) < 2 {
+		return r, false, errors.New("invalid split count")
+	}
+
+	r.Email = s[0]
+	r.Password = s[1]
+	if len(s) > 2 { // email:pass:proxy, the proxy in any format proxies.txt takes
+		r.Proxy = strings.Join(s[2:], ":")
+	}
+
+	return r, true, parseMeta(&r, meta)
+}
+
+func ParseAccounts(accs []string, accType mc.AccType) ([]*mc.MCaccount, []error) {
+	parsed, errs := []*mc.MCaccount{}, []error{}
+	for i, l := range accs {
+		r, ok, err := parseLine(l, accType)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %v: %v", i, err))
+		}
+		if !ok || !r.IsEnabled() {
 			continue
 		}
 
-		acc.DefaultFastHttpHandler()
+		acc, err := r.Account()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %v: %v", i, err))
+			continue
+		}
 
 		parsed = append(parsed, acc)
 
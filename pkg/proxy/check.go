@@ -0,0 +1,221 @@
+package proxy
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+const DefaultCheckTarget = "https://api.minecraftservices.com/"
+
+type CheckResult struct {
+	Proxy     *Proxy
+	Alive     bool
+	Connect   time.Duration // tcp connect through the proxy
+	Handshake time.Duration // tls handshake with the target, 0 for plain http targets
+	Request   time.Duration // full request round trip over the established connection
+	Err       error
+}
+
+// Latency is the time it took to go from nothing to a full response.
+func (r CheckResult) Latency() time.Duration {
+	return r.Connect + r.Handshake + r.Request
+}
+
+// Checker connects through proxies to Target and measures how long each stage takes.
+type Checker struct {
+	Target      string // url to request, DefaultCheckTarget if empty
+	Timeout     time.Duration
+	Concurrency int
+	TLSConfig   *tls.Config // used for https targets, nil for the default config
+}
+
+func NewChecker(target string) *Checker {
+	if target == "" {
+		target = DefaultCheckTarget
+	}
+	return &Checker{
+		Target:      target,
+		Timeout:     time.Second * 10,
+		Concurrency: 20,
+	}
+}
+
+func (c *Checker) Check(p *Proxy) CheckResult {
+	result := CheckResult{Proxy: p}
+
+	target, err := url.Parse(c.Target)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	addr := target.Host
+	if target.Port() == "" {
+		if target.Scheme == "https" {
+			addr = net.JoinHostPort(target.Hostname(), "443")
+		} else {
+			addr = net.JoinHostPort(target.Hostname(), "80")
+		}
+	}
+
+	start := time.Now()
+	conn, err := p.DialerTimeout(c.Timeout)(addr)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer conn.Close()
+	result.Connect = time.Since(start)
+
+	conn.SetDeadline(time.Now().Add(c.Timeout))
+
+	if target.Scheme == "https" {
+		cfg := &tls.Config{}
+		if c.TLSConfig != nil {
+			cfg = c.TLSConfig.Clone()
+		}
+		if cfg.ServerName == "" {
+			cfg.ServerName = target.Hostname()
+		}
+
+		tlsConn := tls.Client(conn, cfg)
+
+		start = time.Now()
+		err = tlsConn.Handshake()
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		result.Handshake = time.Since(start)
+		conn = tlsConn
+	}
+
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(c.Target)
+	req.Header.SetMethod("GET")
+
+	start = time.Now()
+	w := bufio.NewWriter(conn)
+	if err = req.Write(w); err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = resp.Read(bufio.NewReader(conn))
+	}
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Request = time.Since(start)
+
+	if resp.StatusCode() == 407 {
+		result.Err = errors.New("proxy authentication required")
+		return result
+	}
+
+	result.Alive = true
+	return result
+}
+
+// CheckAll checks every proxy, Concurrency at a time. results are in the same order as proxies.
+func (c *Checker) CheckAll(proxies []*Proxy) []CheckResult {
+	results := make([]CheckResult, len(proxies))
+
+	concurrency := c.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for i, p := range proxies {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, p *Proxy) {
+			defer wg.Done()
+			results[i] = c.Check(p)
+			<-sem
+		}(i, p)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// Rank returns the alive proxies from results, fastest first.
+func Rank(results []CheckResult) []*Proxy {
+	alive := []CheckResult{}
+	for _, r := range results {
+		if r.Alive {
+			alive = append(alive, r)
+		}
+	}
+
+	sort.SliceStable(alive, func(i, j int) bool {
+		return alive[i].Latency() < alive[j].Latency()
+	})
+
+	ranked := make([]*Proxy, len(alive))
+	for i, r := range alive {
+		ranked[i] = r.Proxy
+	}
+	return ranked
+}
+
+// MaxShare caps how many times more often Rotation sends through the fastest proxy than the slowest.
+const MaxShare = 4
+
+// Rotation returns the alive proxies from results in the order requests should go through them.
+// A proxy shows up about as many times as it is faster than the slowest one (up to MaxShare),
+// spread out so that the faster proxies get more of the requests without sending them back to back.
+func Rotation(results []CheckResult) []*Proxy {
+	alive := []CheckResult{}
+	for _, r := range results {
+		if r.Alive {
+			alive = append(alive, r)
+		}
+	}
+	sort.SliceStable(alive, func(i, j int) bool {
+		return alive[i].Latency() < alive[j].Latency()
+	})
+	if len(alive) == 0 {
+		return nil
+	}
+
+	slowest := alive[len(alive)-1].Latency()
+	shares := make([]int, len(alive))
+	for i, r := range alive {
+		shares[i] = MaxShare
+		if r.Latency() > 0 {
+			share := int((slowest + r.Latency()/2) / r.Latency())
+			if share < MaxShare {
+				shares[i] = share
+			}
+		}
+		if shares[i] < 1 {
+			shares[i] = 1
+		}
+	}
+
+	rotation := []*Proxy{}
+	for round := 0; round < MaxShare; round++ {
+		for i, r := range alive {
+			if shares[i] > round {
+				rotation = append(rotation, r.Proxy)
+			}
+		}
+	}
+	return rotation
+}
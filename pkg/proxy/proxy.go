@@ -0,0 +1,307 @@
+package proxy
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttpproxy"
+	xproxy "golang.org/x/net/proxy"
+)
+
+type Scheme string
+
+const (
+	HTTP   Scheme = "http"
+	HTTPS  Scheme = "https" // CONNECT proxy reached over tls
+	SOCKS5 Scheme = "socks5"
+)
+
+// represents a single proxy. a nil *Proxy is valid everywhere and means a direct connection.
+type Proxy struct {
+	Scheme   Scheme
+	Host     string
+	Port     string
+	Username string
+	Password string
+}
+
+// Parse accepts host:port, user:pass@host:port, host:port:user:pass and http/https/socks5 urls.
+// ipv6 hosts go in brackets, e.g. [::1]:8080:user:pass.
+func Parse(raw string) (*Proxy, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, errors.New("empty proxy")
+	}
+
+	var p *Proxy
+	var err error
+
+	if strings.Contains(raw, "://") {
+		p, err = parseURL(raw)
+	} else {
+		p, err = parsePlain(raw)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = p.validate(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func parseURL(raw string) (*Proxy, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Proxy{
+		Scheme: Scheme(strings.ToLower(u.Scheme)),
+		Host:   u.Hostname(),
+		Port:   u.Port(),
+	}
+
+	if u.User != nil {
+		p.Username = u.User.Username()
+		p.Password, _ = u.User.Password()
+	}
+
+	return p, nil
+}
+
+func parsePlain(raw string) (*Proxy, error) {
+	p := &Proxy{Scheme: HTTP}
+
+	if i := strings.LastIndex(raw, "@"); i != -1 {
+		auth := strings.SplitN(raw[:i], ":", 2)
+		if len(auth) != 2 {
+			return nil, fmt.Errorf("invalid proxy auth in %v", raw)
+		}
+		p.Username, p.Password = auth[0], auth[1]
+		raw = raw[i+1:]
+	}
+
+	var s []string
+
+	// an ipv6 host is bracketed and full of colons, split it off before the rest
+	if strings.HasPrefix(raw, "[") {
+		end := strings.Index(raw, "]")
+		if end == -1 || !strings.HasPrefix(raw[end+1:], ":") {
+			return nil, fmt.Errorf("invalid ipv6 host in proxy %v", raw)
+		}
+		s = append([]string{raw[1:end]}, strings.Split(raw[end+2:], ":")...)
+	} else {
+		s = strings.Split(raw, ":")
+	}
+
+	switch {
+	case len(s) == 2:
+		p.Host, p.Port = s[0], s[1]
+	case len(s) == 4 && p.Username == "":
+		p.Host, p.Port, p.Username, p.Password = s[0], s[1], s[2], s[3]
+	default:
+		return nil, fmt.Errorf("invalid split count in proxy %v", raw)
+	}
+
+	return p, nil
+}
+
+func (p *Proxy) validate() error {
+	switch p.Scheme {
+	case HTTP, HTTPS, SOCKS5:
+	default:
+		return fmt.Errorf("unsupported proxy scheme %v", p.Scheme)
+	}
+
+	if p.Host == "" {
+		return errors.New("proxy is missing a host")
+	}
+
+	port, err := strconv.Atoi(p.Port)
+	if err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("invalid proxy port %v", p.Port)
+	}
+
+	return nil
+}
+
+// ParseList parses proxies.txt style lines, skipping blank and commented lines.
+func ParseList(lines []string) ([]*Proxy, []error) {
+	parsed, errs := []*Proxy{}, []error{}
+	for i, l := range lines {
+		l = strings.TrimSpace(l)
+
+		if l == "" || l[0] == '#' {
+			continue
+		}
+
+		p, err := Parse(l)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %v: %v", i+1, err))
+			continue
+		}
+
+		parsed = append(parsed, p)
+	}
+	return parsed, errs
+}
+
+func (p *Proxy) Addr() string {
+	if p == nil {
+		return ""
+	}
+	return net.JoinHostPort(p.Host, p.Port)
+}
+
+// URL returns the canonical form of the proxy, nil for a direct connection.
+func (p *Proxy) URL() *url.URL {
+	if p == nil {
+		return nil
+	}
+
+	u := &url.URL{
+		Scheme: string(p.Scheme),
+		Host:   p.Addr(),
+	}
+
+	if p.Username != "" {
+		u.User = url.UserPassword(p.Username, p.Password)
+	}
+
+	return u
+}
+
+func (p *Proxy) String() string {
+	if p == nil {
+		return ""
+	}
+	return p.URL().String()
+}
+
+// Redacted is String without the password, for logging.
+func (p *Proxy) Redacted() string {
+	if p == nil {
+		return "direct"
+	}
+	return p.URL().Redacted()
+}
+
+// Dialer builds a fasthttp dial func that tunnels through the proxy.
+func (p *Proxy) Dialer() fasthttp.DialFunc {
+	return p.DialerTimeout(0)
+}
+
+// DialerTimeout is Dialer with a timeout on connecting to the proxy, 0 means no timeout.
+func (p *Proxy) DialerTimeout(timeout time.Duration) fasthttp.DialFunc {
+	if p == nil {
+		if timeout == 0 {
+			return fasthttp.Dial
+		}
+		return func(addr string) (net.Conn, error) {
+			return fasthttp.DialTimeout(addr, timeout)
+		}
+	}
+
+	if p.Scheme == SOCKS5 {
+		dialer, err := xproxy.FromURL(p.URL(), &net.Dialer{Timeout: timeout})
+		return func(addr string) (net.Conn, error) {
+			if err != nil {
+				return nil, err
+			}
+			return dialer.Dial("tcp", addr)
+		}
+	}
+
+	if p.Scheme == HTTPS {
+		return func(addr string) (net.Conn, error) {
+			return p.dialTLS(addr, timeout)
+		}
+	}
+
+	// fasthttpproxy wants user:pass@host:port for CONNECT proxies
+	addr := p.Addr()
+	if p.Username != "" {
+		addr = p.Username + ":" + p.Password + "@" + addr
+	}
+	return fasthttpproxy.FasthttpHTTPDialerTimeout(addr, timeout)
+}
+
+// dialTLS opens a tls connection to an https proxy and has it CONNECT to addr,
+// timeout covers the whole exchange and 0 means no timeout.
+func (p *Proxy) dialTLS(addr string, timeout time.Duration) (net.Conn, error) {
+	conn, err := (&net.Dialer{Timeout: timeout}).Dial("tcp", p.Addr())
+	if err != nil {
+		return nil, err
+	}
+	if timeout > 0 {
+		conn.SetDeadline(time.Now().Add(timeout))
+	}
+
+	tlsConn := tls.Client(conn, &tls.Config{ServerName: p.Host})
+	if err = tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("tls handshake with proxy %v: %w", p.Redacted(), err)
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+	if p.Username != "" {
+		auth := base64.StdEncoding.EncodeToString([]byte(p.Username + ":" + p.Password))
+		req.Header.Set("Proxy-Authorization", "Basic "+auth)
+	}
+	if err = req.Write(tlsConn); err != nil {
+		tlsConn.Close()
+		return nil, err
+	}
+
+	br := bufio.NewReader(tlsConn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		tlsConn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		tlsConn.Close()
+		return nil, fmt.Errorf("proxy %v refused CONNECT to %v: %v", p.Redacted(), addr, resp.Status)
+	}
+	// nothing is sent past the CONNECT response until we talk, anything buffered would be lost
+	if br.Buffered() > 0 {
+		tlsConn.Close()
+		return nil, fmt.Errorf("proxy %v sent data after its CONNECT response", p.Redacted())
+	}
+
+	if timeout > 0 {
+		conn.SetDeadline(time.Time{})
+	}
+	return tlsConn, nil
+}
+
+// Transport builds a net/http transport that routes through the proxy.
+func (p *Proxy) Transport() *http.Transport {
+	tr := &http.Transport{}
+
+	if p != nil {
+		tr.Proxy = http.ProxyURL(p.URL())
+	}
+
+	return tr
+}
@@ -0,0 +1,223 @@
+package selector
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/Kqzz/MCsniperGO/pkg/mc"
+)
+
+// Selector picks accounts by group, tag or type.
+//
+// an expression is made of terms joined with and (&), or (| or ,), not (!) and parentheses.
+// a term is group:<name>, tag:<name>, type:<MS|GC|GP>, email:<address> or a bare name matching any
+// group, tag or type. names are case insensitive and may use * and ? wildcards, e.g.
+// "group:team-a and not tag:reserve" or "tag:fresh-* | GP".
+//
+// not binds tighter than and, which binds tighter than or. a bare and, or or not is always an
+// operator, so a group or tag with one of those names has to be written with its key (tag:or).
+// wildcards follow path.Match, * and ? don't match a /.
+type Selector struct {
+	expr node
+	raw  string
+}
+
+// Parse compiles expr, an empty expression selects every account
+func Parse(expr string) (*Selector, error) {
+	p := &exprParser{tokens: tokenize(expr)}
+	if len(p.tokens) == 0 {
+		return &Selector{raw: expr}, nil
+	}
+
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	return &Selector{expr: n, raw: expr}, nil
+}
+
+func (s *Selector) String() string {
+	if s.expr == nil {
+		return "all accounts"
+	}
+	return s.raw
+}
+
+func (s *Selector) Match(acc *mc.MCaccount) bool {
+	return s.expr == nil || s.expr.match(acc)
+}
+
+// Select returns the accounts that match, in their original order
+func (s *Selector) Select(accounts []*mc.MCaccount) []*mc.MCaccount {
+	picked := []*mc.MCaccount{}
+	for _, acc := range accounts {
+		if s.Match(acc) {
+			picked = append(picked, acc)
+		}
+	}
+	return picked
+}
+
+type node interface {
+	match(acc *mc.MCaccount) bool
+}
+
+type and struct{ left, right node }
+type or struct{ left, right node }
+type not struct{ inner node }
+
+type term struct {
+	key     string // group, tag, type, email or "" for any of group, tag and type
+	pattern string
+}
+
+func (n and) match(acc *mc.MCaccount) bool { return n.left.match(acc) && n.right.match(acc) }
+func (n or) match(acc *mc.MCaccount) bool  { return n.left.match(acc) || n.right.match(acc) }
+func (n not) match(acc *mc.MCaccount) bool { return !n.inner.match(acc) }
+
+func (t term) matches(value string) bool {
+	ok, _ := path.Match(t.pattern, strings.ToLower(value))
+	return ok
+}
+
+func (t term) match(acc *mc.MCaccount) bool {
+	switch t.key {
+	case "group":
+		return t.matches(acc.Group)
+	case "type":
+		return t.matches(string(acc.Type))
+	case "email":
+		return t.matches(acc.Email)
+	case "tag":
+		for _, tag := range acc.Tags {
+			if t.matches(tag) {
+				return true
+			}
+		}
+		return false
+	}
+	return term{"group", t.pattern}.match(acc) || term{"tag", t.pattern}.match(acc) || term{"type", t.pattern}.match(acc)
+}
+
+func tokenize(expr string) []string {
+	tokens := []string{}
+	current := strings.Builder{}
+
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+
+	for _, r := range expr {
+		switch {
+		case unicode.IsSpace(r):
+			flush()
+		case strings.ContainsRune("()&|,!", r):
+			flush()
+			tokens = append(tokens, string(r))
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+type exprParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *exprParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return strings.ToLower(p.tokens[p.pos])
+	}
+	return ""
+}
+
+func (p *exprParser) or() (node, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for tok := p.peek(); tok == "or" || tok == "|" || tok == ","; tok = p.peek() {
+		p.pos++
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = or{left, right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) and() (node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for tok := p.peek(); tok == "and" || tok == "&"; tok = p.peek() {
+		p.pos++
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = and{left, right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) unary() (node, error) {
+	switch tok := p.peek(); tok {
+	case "":
+		return nil, fmt.Errorf("expression ends early")
+	case "not", "!":
+		p.pos++
+		inner, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return not{inner}, nil
+	case "(":
+		p.pos++
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return inner, nil
+	case ")", "and", "&", "or", "|", ",":
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+
+	raw := p.tokens[p.pos]
+	p.pos++
+
+	key, pattern := "", raw
+	if i := strings.Index(raw, ":"); i != -1 {
+		key, pattern = strings.ToLower(raw[:i]), raw[i+1:]
+		switch key {
+		case "group", "tag", "type", "email":
+		default:
+			return nil, fmt.Errorf("unknown key %q in %q, use group, tag, type or email", key, raw)
+		}
+	}
+	if pattern == "" {
+		return nil, fmt.Errorf("%q has no value", raw)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("bad pattern %q: %v", pattern, err)
+	}
+
+	return term{key: key, pattern: strings.ToLower(pattern)}, nil
+}
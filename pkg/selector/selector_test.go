@@ -0,0 +1,104 @@
+package selector
+
+import (
+	"testing"
+
+	"github.com/Kqzz/MCsniperGO/pkg/mc"
+)
+
+var accounts = []*mc.MCaccount{
+	{Email: "a@example.com", Type: mc.Ms, Group: "team-a", Tags: []string{"fresh-1"}},
+	{Email: "b@example.com", Type: mc.Ms, Group: "team-a", Tags: []string{"reserve"}},
+	{Email: "c@example.com", Type: mc.MsGp, Group: "team-b", Tags: []string{"fresh-2", "or"}},
+	{Email: "d@example.com", Type: mc.MsPr, Group: "eu/team-c"},
+}
+
+// emails of the accounts expr selects, in order
+func selected(t *testing.T, expr string) string {
+	s, err := Parse(expr)
+	if err != nil {
+		t.Fatalf("Parse(%q) failed: %v", expr, err)
+	}
+	picked := ""
+	for _, acc := range s.Select(accounts) {
+		picked += acc.Email[:1]
+	}
+	return picked
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"", "abcd"},
+		{"group:team-a", "ab"},
+		{"GROUP:TEAM-A", "ab"},
+		{"tag:reserve", "b"},
+		{"type:GP", "c"},
+		{"email:c@example.com", "c"},
+		{"team-b", "c"},
+		{"MS", "ab"},
+
+		// and binds tighter than or, not tighter than both
+		{"group:team-a and tag:reserve or type:GC", "bd"},
+		{"type:GC or group:team-a and tag:reserve", "bd"},
+		{"not group:team-a and not type:GC", "c"},
+		{"!group:team-a & !type:GC", "c"},
+		{"group:team-a & !tag:reserve | type:GP", "ac"},
+
+		// parentheses
+		{"group:team-a and (tag:reserve or tag:fresh-*)", "ab"},
+		{"not (group:team-a or type:GP)", "d"},
+		{"(type:MS, type:GP) & tag:fresh-*", "ac"},
+
+		// globs
+		{"tag:fresh-*", "ac"},
+		{"tag:fresh-?", "ac"},
+		{"group:team-[ab]", "abc"},
+		{"email:*@example.com", "abcd"},
+		{"group:*", "abc"}, // * stops at a /
+		{"group:*team-c", ""},
+		{"group:eu/*", "d"},
+
+		// operator words need their key to be used as names
+		{"tag:or", "c"},
+	}
+
+	for _, tt := range tests {
+		if got := selected(t, tt.expr); got != tt.want {
+			t.Errorf("%q selected %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"and",
+		"or",
+		"group:team-a and",
+		"group:team-a or or tag:x",
+		"(group:team-a",
+		"group:team-a)",
+		"not",
+		"group:",
+		"proxy:1.2.3.4",
+		"tag:[",
+		"group:a group:b",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	s, _ := Parse("")
+	if s.String() != "all accounts" {
+		t.Errorf("empty selector String() = %q", s.String())
+	}
+	s, _ = Parse("tag:x")
+	if s.String() != "tag:x" {
+		t.Errorf("String() = %q, want the expression", s.String())
+	}
+}
@@ -0,0 +1,186 @@
+package watchlist
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Kqzz/MCsniperGO/claimer"
+	"github.com/Kqzz/MCsniperGO/log"
+	"github.com/Kqzz/MCsniperGO/pkg/availability"
+	"github.com/Kqzz/MCsniperGO/pkg/mc"
+)
+
+// SnipeWindow is how long a snipe of a watched name runs, counted from when it's started.
+// a name nobody could claim in that long isn't worth holding up the rest of the queue for.
+const SnipeWindow = time.Minute * 10
+
+// SnipeRange is the drop range of a snipe of a watched name starting now, snipes move it
+// to start once their accounts are logged in (claimer.Options.StartAfterAuth)
+func SnipeRange() mc.DropRange {
+	now := time.Now()
+	return mc.DropRange{Start: now, End: now.Add(SnipeWindow)}
+}
+
+// Runner watches every name on the watchlist and acts on the ones that become available.
+// snipes run one at a time, the highest priority queued name goes next.
+type Runner struct {
+	store   *Store
+	watcher *availability.Watcher
+	snipe   func(Entry) error
+
+	mu      sync.Mutex
+	pending []string
+	wake    chan struct{}
+	stop    chan struct{}
+}
+
+// NewRunner checks the store's names every interval. snipe snipes an entry within SnipeRange and
+// blocks until it's over, entries set to Snipe only alert if it's nil.
+func NewRunner(store *Store, interval time.Duration, snipe func(Entry) error) *Runner {
+	r := &Runner{
+		store:   store,
+		watcher: availability.NewWatcher(interval),
+		snipe:   snipe,
+		wake:    make(chan struct{}, 1),
+	}
+	r.watcher.Subscribe(r.changed)
+	return r
+}
+
+func (r *Runner) Start() {
+	r.Sync()
+	r.watcher.Start()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stop == nil {
+		r.stop = make(chan struct{})
+		go r.sniper(r.stop)
+	}
+}
+
+func (r *Runner) Stop() {
+	r.watcher.Stop()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stop != nil {
+		close(r.stop)
+		r.stop = nil
+	}
+}
+
+// Sync makes the watcher follow the store after entries were added or removed
+func (r *Runner) Sync() {
+	entries := r.store.List()
+
+	names := []string{}
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+
+	for _, name := range r.watcher.Names() {
+		if _, ok := r.store.Get(name); !ok {
+			r.watcher.Remove(name)
+		}
+	}
+	r.watcher.Add(names...)
+}
+
+func (r *Runner) changed(change availability.Change) {
+	r.store.update(change.Name, func(e *Entry) {
+		e.State = change.To
+		e.LastChange = change.Time
+	})
+
+	if change.To != availability.Available {
+		return
+	}
+
+	entry, ok := r.store.Get(change.Name)
+	if !ok {
+		return
+	}
+
+	message := fmt.Sprintf("watched name %v is available", entry.Name)
+	if entry.Notes != "" {
+		message += fmt.Sprintf(" (%v)", entry.Notes)
+	}
+	log.Log("success", message)
+	claimer.Publish(claimer.Event{Type: claimer.EventAvailable, Name: entry.Name, Message: message})
+
+	if entry.Action == Snipe && r.snipe != nil {
+		r.enqueue(entry.Name)
+	}
+}
+
+func (r *Runner) enqueue(name string) {
+	r.mu.Lock()
+	for _, queued := range r.pending {
+		if queued == name {
+			r.mu.Unlock()
+			return
+		}
+	}
+	r.pending = append(r.pending, name)
+	r.mu.Unlock()
+
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next pops the queued name with the highest priority, false if nothing is queued
+func (r *Runner) next() (Entry, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	best := -1
+	var bestEntry Entry
+	for i, name := range r.pending {
+		entry, ok := r.store.Get(name)
+		if !ok {
+			continue
+		}
+		if best == -1 || entry.Priority > bestEntry.Priority {
+			best, bestEntry = i, entry
+		}
+	}
+	if best == -1 {
+		r.pending = nil
+		return Entry{}, false
+	}
+
+	r.pending = append(r.pending[:best], r.pending[best+1:]...)
+	return bestEntry, true
+}
+
+func (r *Runner) sniper(stop chan struct{}) {
+	for {
+		entry, ok := r.next()
+		if !ok {
+			select {
+			case <-r.wake:
+				continue
+			case <-stop:
+				return
+			}
+		}
+
+		// it may have been taken again while other snipes ran
+		if r.watcher.State(entry.Name) != availability.Available {
+			continue
+		}
+
+		r.store.update(entry.Name, func(e *Entry) {
+			e.LastSnipeAt = time.Now()
+		})
+
+		log.Log("info", "starting snipe of watched name %v", entry.Name)
+		if err := r.snipe(entry); err != nil {
+			log.Log("err", "snipe of watched name %v failed: %v", entry.Name, err)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package watchlist
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Kqzz/MCsniperGO/pkg/availability"
+	"github.com/Kqzz/MCsniperGO/pkg/mc"
+	"github.com/Kqzz/MCsniperGO/pkg/selector"
+)
+
+const DefaultPath = "watchlist.json"
+
+type Action string
+
+const (
+	Alert Action = "alert" // tell the user the name is available
+	Snipe Action = "snipe" // start an infinite snipe of the name
+)
+
+// Entry is a name we want, and what to do once it's free
+type Entry struct {
+	Name        string             `json:"name"`
+	Priority    int                `json:"priority"` // higher names are checked and sniped first
+	Notes       string             `json:"notes,omitempty"`
+	Action      Action             `json:"action"`
+	Select      string             `json:"select,omitempty"` // selector expression picking the accounts that snipe it, every account if empty
+	Added       time.Time          `json:"added"`
+	State       availability.State `json:"state"`
+	LastChange  time.Time          `json:"lastChange,omitempty"`
+	LastSnipeAt time.Time          `json:"lastSnipeAt,omitempty"`
+}
+
+func (e Entry) Validate() error {
+	if e.Name == "" {
+		return errors.New("name is empty")
+	}
+	switch e.Action {
+	case Alert, Snipe:
+	default:
+		return fmt.Errorf("unknown action %q", e.Action)
+	}
+	if _, err := selector.Parse(e.Select); err != nil {
+		return fmt.Errorf("invalid account selector: %v", err)
+	}
+	return nil
+}
+
+// Accounts picks the accounts of all that the entry's selector matches
+func (e Entry) Accounts(all []*mc.MCaccount) ([]*mc.MCaccount, error) {
+	sel, err := selector.Parse(e.Select)
+	if err != nil {
+		return nil, err
+	}
+	return sel.Select(all), nil
+}
+
+// Store is the watchlist kept in a json file, every change is written back right away
+type Store struct {
+	mu      sync.Mutex
+	path    string
+	Entries []Entry `json:"entries"`
+}
+
+// Load reads the watchlist at path, an empty one if it doesn't exist yet
+func Load(path string) (*Store, error) {
+	store := &Store{path: path, Entries: []Entry{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return store, err
+	}
+
+	err = json.Unmarshal(data, store)
+	if store.Entries == nil {
+		store.Entries = []Entry{}
+	}
+	return store, err
+}
+
+// save writes the store, the caller holds mu
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
+
+func (s *Store) find(name string) int {
+	for i, e := range s.Entries {
+		if strings.EqualFold(e.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// List returns every entry, highest priority first
+func (s *Store) List() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := append([]Entry{}, s.Entries...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Priority > entries[j].Priority
+	})
+	return entries
+}
+
+func (s *Store) Get(name string) (Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i := s.find(name); i != -1 {
+		return s.Entries[i], true
+	}
+	return Entry{}, false
+}
+
+// Put adds e, or replaces the settings of the entry with the same name while keeping what was observed about it
+func (s *Store) Put(e Entry) error {
+	if e.Action == "" {
+		e.Action = Alert
+	}
+	if err := e.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i := s.find(e.Name); i != -1 {
+		current := s.Entries[i]
+		current.Priority, current.Notes, current.Action, current.Select = e.Priority, e.Notes, e.Action, e.Select
+		s.Entries[i] = current
+	} else {
+		e.Added = time.Now()
+		if e.State == "" {
+			e.State = availability.Unknown
+		}
+		s.Entries = append(s.Entries, e)
+	}
+	return s.save()
+}
+
+func (s *Store) Remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.find(name)
+	if i == -1 {
+		return fmt.Errorf("%v isn't on the watchlist", name)
+	}
+	s.Entries = append(s.Entries[:i], s.Entries[i+1:]...)
+	return s.save()
+}
+
+// update applies fn to the entry named name and saves, doing nothing if it was removed meanwhile
+func (s *Store) update(name string, fn func(e *Entry)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.find(name)
+	if i == -1 {
+		return nil
+	}
+	fn(&s.Entries[i])
+	return s.save()
+}
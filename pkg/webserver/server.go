@@ -3,19 +3,27 @@ package webserver
 import (
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"log"
 	"net/http"
 	"os"
+	"strings"
+	"sync"
 	"time" // Will likely be needed for drop range
 
 	// Adjust these imports based on actual MCsniperGO package structure
 	"github.com/Kqzz/MCsniperGO/claimer"
-	cliutils "github.com/Kqzz/MCsniperGO/cmd/cli" // Import the package containing getAccounts
+	mclog "github.com/Kqzz/MCsniperGO/log"
+	"github.com/Kqzz/MCsniperGO/pkg/availability"
 	mc "github.com/Kqzz/MCsniperGO/pkg/mc"
+	"github.com/Kqzz/MCsniperGO/pkg/notify"
 	"github.com/Kqzz/MCsniperGO/pkg/parser"
+	"github.com/Kqzz/MCsniperGO/pkg/proxy"
+	"github.com/Kqzz/MCsniperGO/pkg/selector"
+	"github.com/Kqzz/MCsniperGO/pkg/watchlist"
 )
 
 //go:embed all:../../web/dist
@@ -23,8 +31,32 @@ var embeddedFiles embed.FS
 
 // SnipeRequest defines the structure for incoming snipe requests
 type SnipeRequest struct {
-	Username string `json:"username"`
-	Delay    int    `json:"delay"` // Still not directly used by core logic shown previously
+	Username        string `json:"username"`
+	Delay           int    `json:"delay"`           // Milliseconds between requests, used when no schedule is given
+	CheckProxies    bool   `json:"checkProxies"`    // Health check proxies before the drop and skip dead ones
+	MaxProxyLatency int    `json:"maxProxyLatency"` // Also skip proxies slower than this many ms, 0 keeps every alive one
+	PrewarmSeconds  int    `json:"prewarmSeconds"`  // Open connections this many seconds before the drop
+	Calibrate       bool   `json:"calibrate"`       // Measure local clock offset and adjust the drop time
+	NTPServer       string `json:"ntpServer"`       // Calibrate against an SNTP server instead of API Date headers
+	Schedule        string `json:"schedule"`        // even, burst[:gap], decay[:power], fixed:<ms> or file:<path>
+	Workers         int    `json:"workers"`         // Max concurrent requests, sized from the schedule when 0
+	PreciseMs       int    `json:"preciseMs"`       // Busy-wait sends in this many ms after the window opens
+	Skin            string `json:"skin"`            // Skin url or local path applied once the name is claimed
+	SkinVariant     string `json:"skinVariant"`     // classic or slim
+	FollowUp        string `json:"followUp"`        // Username to snipe next with the accounts that didn't claim
+	FollowUpRange   string `json:"followUpRange"`   // start-end unix timestamps or "infinite"
+	NoVerify        bool   `json:"noVerify"`        // End the snipe on the first 200 without checking the name landed
+	Select          string `json:"select"`          // Account selector expression, every account if empty
+	ProxyPolicy     string `json:"proxyPolicy"`     // rotate (default) or bind each account to one proxy
+	AuthConcurrency int    `json:"authConcurrency"` // Accounts logging in at once, 4 when 0
+	AuthRetries     int    `json:"authRetries"`     // Retries of a transient login failure, 2 when 0, negative for none
+	WatchInterval   int    `json:"watchInterval"`   // Seconds between availability checks of the name, 60 when 0
+}
+
+// GiftCodesRequest holds email:password:giftcode[:proxy] lines to redeem
+type GiftCodesRequest struct {
+	Lines           string `json:"lines"`
+	AuthConcurrency int    `json:"authConcurrency"` // Accounts logging in at once, 4 when 0
 }
 
 // ConfigRequest defines the structure for incoming config save requests
@@ -43,6 +75,135 @@ type ConfigResponse struct {
 	// TODO: Add Proxies field later
 }
 
+// ProxyCheckResponse is a single proxy health check result
+type ProxyCheckResponse struct {
+	Proxy       string `json:"proxy"`
+	Alive       bool   `json:"alive"`
+	ConnectMs   int64  `json:"connectMs"`
+	HandshakeMs int64  `json:"handshakeMs"`
+	RequestMs   int64  `json:"requestMs"`
+	LatencyMs   int64  `json:"latencyMs"`
+	Error       string `json:"error,omitempty"`
+}
+
+// StatsResponse reports the progress of the current snipe
+type StatsResponse struct {
+	Total           int                          `json:"total"`
+	TooManyRequests int                          `json:"tooManyRequests"`
+	Duplicate       int                          `json:"duplicate"`
+	NotAllowed      int                          `json:"notAllowed"`
+	Success         int                          `json:"success"`
+	Cooldowns       int                          `json:"cooldowns"`
+	ProxyPenalties  int                          `json:"proxyPenalties"`
+	Unauthorized    int                          `json:"unauthorized"`
+	Quarantined     int                          `json:"quarantined"`
+	Removed         int                          `json:"removed"`
+	LateSends       int                          `json:"lateSends"`
+	Unconfirmed     int                          `json:"unconfirmed"`
+	ClockOffsetMs   int64                        `json:"clockOffsetMs"`
+	LatencyMs       int64                        `json:"latencyMs"`
+	Warnings        []string                     `json:"warnings"`
+	Types           map[string]TypeStatsResponse `json:"types"`
+	Drift           []BucketResponse             `json:"drift"` // How late sends of the current snipe went out
+	RoundTrip       []BucketResponse             `json:"roundTrip"`
+	Auth            AuthResponse                 `json:"auth"`      // Logins of the current snipe
+	GiftCodes       AuthResponse                 `json:"giftCodes"` // The latest gift code redemptions
+}
+
+// AuthResponse is the progress of the logins before a snipe or of gift code redemptions, accounts are listed once they're all done
+type AuthResponse struct {
+	Total    int                   `json:"total"`
+	Done     int                   `json:"done"`
+	Failed   int                   `json:"failed"`
+	Accounts []AuthAccountResponse `json:"accounts"`
+}
+
+type AuthAccountResponse struct {
+	Account  string `json:"account"` // Redacted
+	Type     string `json:"type"`
+	Proxy    string `json:"proxy"`
+	Attempts int    `json:"attempts"`
+	TookMs   int64  `json:"tookMs"`
+	Error    string `json:"error,omitempty"`
+}
+
+// WebhookTestResponse is the outcome of sending a test event to one webhook
+type WebhookTestResponse struct {
+	URL   string `json:"url"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// BucketResponse is one histogram bucket, UpperMs is 0 for the last one that holds everything above the rest
+type BucketResponse struct {
+	UpperMs float64 `json:"upperMs"`
+	Count   int     `json:"count"`
+}
+
+// TypeStatsResponse is the part of the stats kept per account type (MS, GC, GP)
+type TypeStatsResponse struct {
+	Total           int `json:"total"`
+	Success         int `json:"success"`
+	TooManyRequests int `json:"tooManyRequests"`
+}
+
+// EventResponse is a single claimer event
+type EventResponse struct {
+	Type    string `json:"type"`
+	Time    int64  `json:"time"` // Unix milliseconds
+	Name    string `json:"name"`
+	AccType string `json:"accType"`
+	AccNum  int    `json:"accNum"`
+	Proxy   string `json:"proxy"`
+	Account string `json:"account"`
+	Count   int    `json:"count"`
+	Message string `json:"message"`
+}
+
+// Keeps the most recent claimer events around for the UI
+const maxEvents = 200
+
+var (
+	eventsMu sync.Mutex
+	events   []EventResponse
+)
+
+// AccountsPath is the accounts file, used instead of gc.txt, gp.txt and ms.txt when it exists
+var AccountsPath = parser.DefaultAccountsPath
+
+// WebhooksPath is where the webhook config is kept, shared with the CLI
+var WebhooksPath = notify.DefaultConfigPath
+
+var notifier *notify.Notifier
+
+// WatchlistPath is where the watchlist is kept, shared with the CLI
+var WatchlistPath = watchlist.DefaultPath
+
+var (
+	watchStore  *watchlist.Store
+	watchRunner *watchlist.Runner
+)
+
+func recordEvent(e claimer.Event) {
+	eventsMu.Lock()
+	defer eventsMu.Unlock()
+
+	events = append(events, EventResponse{
+		Type:    string(e.Type),
+		Time:    e.Time.UnixMilli(),
+		Name:    e.Name,
+		AccType: string(e.AccType),
+		AccNum:  e.AccNum,
+		Proxy:   e.Proxy,
+		Account: e.Account,
+		Count:   e.Count,
+		Message: e.Message,
+	})
+	if len(events) > maxEvents {
+		events = events[len(events)-maxEvents:]
+	}
+}
+
 // --- Helper Functions ---
 
 // Reads config relative to executable's CWD (project root)
@@ -74,6 +235,19 @@ func writeConfigFile(filename string, data string) error {
 // Simplified version of getAccounts from cmd/cli/util.go
 // Assumes config files are in the current working directory (project root)
 func getAccounts() ([]*mc.MCaccount, error) {
+	records, err := parser.LoadAccountsFile(AccountsPath)
+	if err == nil {
+		accounts, parseErrors := parser.RecordAccounts(records)
+		logErrors(parseErrors)
+		if len(accounts) == 0 {
+			return accounts, fmt.Errorf("no enabled accounts found or parsed successfully in %s", AccountsPath)
+		}
+		return accounts, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to load %s: %v", AccountsPath, err)
+	}
+
 	giftCodeLines, _ := parser.ReadLines("gc.txt")
 	gamepassLines, _ := parser.ReadLines("gp.txt")
 	microsoftLines, _ := parser.ReadLines("ms.txt")
@@ -96,6 +270,19 @@ func getAccounts() ([]*mc.MCaccount, error) {
 	return accounts, nil
 }
 
+// Parses proxies.txt from the current working directory (project root)
+func getProxies() ([]*proxy.Proxy, error) {
+	lines, err := parser.ReadLines("proxies.txt")
+	if err != nil {
+		return nil, err
+	}
+
+	proxies, parseErrors := proxy.ParseList(lines)
+	logErrors(parseErrors)
+
+	return proxies, nil
+}
+
 func logErrors(errors []error) {
 	for _, err := range errors {
 		if err != nil {
@@ -188,7 +375,44 @@ func handleSnipe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Printf("Received snipe request for username: %s (Delay: %dms - currently ignored)", req.Username, req.Delay)
+	// A bare delay is a fixed interval schedule
+	if req.Schedule == "" && req.Delay > 0 {
+		req.Schedule = fmt.Sprintf("fixed:%d", req.Delay)
+	}
+
+	scheduler, err := claimer.ParseScheduler(req.Schedule)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid schedule: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	postClaim := claimer.PostClaimConfig{
+		Skin:        req.Skin,
+		SkinVariant: req.SkinVariant,
+		HistoryPath: claimer.DefaultHistoryPath,
+		FollowUp:    req.FollowUp,
+	}
+	if req.FollowUp != "" {
+		postClaim.FollowUpRange, err = mclog.ParseDropRange(req.FollowUpRange)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid follow-up range: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	accountSelector, err := selector.Parse(req.Select)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid account selector: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	proxyPolicy, err := claimer.ParseProxyPolicy(req.ProxyPolicy)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid proxy policy: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("Received snipe request for username: %s (Schedule: %s)", req.Username, scheduler.Name())
 
 	// --- Direct Call Logic ---
 	go func() { // Run snipe logic in a goroutine to avoid blocking the HTTP response
@@ -199,21 +423,25 @@ func handleSnipe(w http.ResponseWriter, r *http.Request) {
 		dropRange := mc.DropRange{Start: dropTime, End: dropTime.Add(500 * time.Millisecond)} // Example range
 		
 		log.Printf("Loading accounts for snipe...")
-		// Use getAccounts from the cliutils package
-		accounts, accErr := cliutils.GetAccounts("gc.txt", "gp.txt", "ms.txt") 
+		accounts, accErr := getAccounts()
 		if accErr != nil {
 			log.Printf("Snipe Failed for %s: Could not load accounts: %v", username, accErr)
 			// TODO: Communicate this failure back to the user (e.g., WebSocket/SSE)
 			return
 		}
-		log.Printf("Found %d accounts.", len(accounts))
+		accounts = accountSelector.Select(accounts)
+		if len(accounts) == 0 {
+			log.Printf("Snipe Failed for %s: no accounts match %s", username, accountSelector)
+			return
+		}
+		log.Printf("Found %d accounts matching %s.", len(accounts), accountSelector)
 
 		log.Printf("Loading proxies for snipe...")
 		// TODO: Handle proxies properly (load from proxies.txt or web UI config)
-		proxies, proxyErr := parser.ReadLines("proxies.txt")
+		proxies, proxyErr := getProxies()
 		if proxyErr != nil {
 			log.Printf("Warning: Could not load proxies.txt: %v. Proceeding without proxies.", proxyErr)
-			proxies = []string{} // Ensure it's an empty slice, not nil
+			proxies = []*proxy.Proxy{} // Ensure it's an empty slice, not nil
 		} else {
 			log.Printf("Found %d proxies.", len(proxies))
 		}
@@ -222,7 +450,22 @@ func handleSnipe(w http.ResponseWriter, r *http.Request) {
 		log.Printf("Starting snipe for %s at ~%s...", username, dropRange.Start.Format(time.RFC3339))
 
 		// Call the core claimer function directly
-		claimErr := claimer.ClaimWithinRange(username, dropRange, accounts, proxies)
+		opts := claimer.Options{
+			CheckProxies:    req.CheckProxies,
+			MaxProxyLatency: time.Duration(req.MaxProxyLatency) * time.Millisecond,
+			Prewarm:         time.Duration(req.PrewarmSeconds) * time.Second,
+			Calibrate:       req.Calibrate || req.NTPServer != "",
+			NTPServer:       req.NTPServer,
+			Scheduler:       scheduler,
+			Workers:         req.Workers,
+			Precise:         time.Duration(req.PreciseMs) * time.Millisecond,
+			PostClaim:       postClaim.Steps(),
+			SkipVerify:      req.NoVerify,
+			ProxyPolicy:     proxyPolicy,
+			WatchInterval:   time.Duration(req.WatchInterval) * time.Second,
+			Auth:            claimer.AuthOptions{Concurrency: req.AuthConcurrency, Retries: req.AuthRetries},
+		}
+		claimErr := claimer.ClaimWithinRange(username, dropRange, accounts, proxies, opts)
 
 		if claimErr != nil {
 			log.Printf("Snipe completed for %s with error: %v", username, claimErr)
@@ -240,6 +483,316 @@ func handleSnipe(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// ProxyCheckTargets are the urls handleProxyCheck lets ?target= pick, so the server can't be made to request anything else
+var ProxyCheckTargets = []string{proxy.DefaultCheckTarget, "https://api.mojang.com/"}
+
+// handleProxyCheck health checks proxies.txt, optionally against ?target=<url> from ProxyCheckTargets
+func handleProxyCheck(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Only GET method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	target := r.URL.Query().Get("target")
+	if target != "" && !allowedCheckTarget(target) {
+		http.Error(w, fmt.Sprintf("target must be one of %v", strings.Join(ProxyCheckTargets, ", ")), http.StatusBadRequest)
+		return
+	}
+
+	proxies, err := getProxies()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load proxies.txt: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	results := proxy.NewChecker(target).CheckAll(proxies)
+
+	resp := []ProxyCheckResponse{}
+	for _, res := range results {
+		check := ProxyCheckResponse{
+			Proxy:       res.Proxy.Redacted(),
+			Alive:       res.Alive,
+			ConnectMs:   res.Connect.Milliseconds(),
+			HandshakeMs: res.Handshake.Milliseconds(),
+			RequestMs:   res.Request.Milliseconds(),
+			LatencyMs:   res.Latency().Milliseconds(),
+		}
+		if res.Err != nil {
+			check.Error = res.Err.Error()
+		}
+		resp = append(resp, check)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		log.Printf("Error encoding proxy check response: %v", err)
+	}
+}
+
+func allowedCheckTarget(target string) bool {
+	for _, allowed := range ProxyCheckTargets {
+		if target == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func bucketsResponse(h *claimer.Histogram) []BucketResponse {
+	buckets := []BucketResponse{}
+	if h == nil {
+		return buckets
+	}
+	for _, b := range h.Buckets() {
+		buckets = append(buckets, BucketResponse{UpperMs: float64(b.Upper) / float64(time.Millisecond), Count: b.Count})
+	}
+	return buckets
+}
+
+// Returns the webhook config on GET, replaces and saves it on POST
+func handleWebhooks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var config notify.Config
+		if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+			http.Error(w, fmt.Sprintf("Error decoding request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		if config.Webhooks == nil {
+			config.Webhooks = []notify.Webhook{}
+		}
+		if err := config.Validate(); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid webhook config: %v", err), http.StatusBadRequest)
+			return
+		}
+		if err := config.Save(WebhooksPath); err != nil {
+			log.Printf("Error writing %s: %v", WebhooksPath, err)
+			http.Error(w, fmt.Sprintf("Failed to write %s: %v", WebhooksPath, err), http.StatusInternalServerError)
+			return
+		}
+		notifier.SetConfig(config)
+		log.Printf("Saved %d webhook(s).", len(config.Webhooks))
+	default:
+		http.Error(w, "Only GET and POST methods are allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(notifier.Config())
+	if err != nil {
+		log.Printf("Error encoding webhook config: %v", err)
+	}
+}
+
+// Snipes a watched name that became available for watchlist.SnipeWindow
+func snipeWatched(entry watchlist.Entry) error {
+	accounts, err := getAccounts()
+	if err != nil {
+		return err
+	}
+
+	accounts, err = entry.Accounts(accounts)
+	if err != nil {
+		return err
+	}
+	if len(accounts) == 0 {
+		return fmt.Errorf("no accounts match %q", entry.Select)
+	}
+
+	proxies, err := getProxies()
+	if err != nil {
+		log.Printf("Warning: Could not load proxies.txt: %v. Proceeding without proxies.", err)
+	}
+
+	opts := claimer.Options{
+		PostClaim:      claimer.PostClaimConfig{HistoryPath: claimer.DefaultHistoryPath}.Steps(),
+		StartAfterAuth: true,
+	}
+	return claimer.ClaimWithinRange(entry.Name, watchlist.SnipeRange(), accounts, proxies, opts)
+}
+
+func authResponse(progress claimer.AuthProgress) AuthResponse {
+	resp := AuthResponse{Total: progress.Total, Done: progress.Done, Failed: progress.Failed, Accounts: []AuthAccountResponse{}}
+	for _, r := range progress.Results {
+		account := AuthAccountResponse{
+			Account:  claimer.RedactAccount(r.Account),
+			Type:     string(r.Account.Type),
+			Proxy:    r.Account.Proxy.Redacted(),
+			Attempts: r.Attempts,
+			TookMs:   r.Took.Milliseconds(),
+		}
+		if r.Err != nil {
+			account.Error = r.Err.Error()
+		}
+		resp.Accounts = append(resp.Accounts, account)
+	}
+	return resp
+}
+
+// Lists the watchlist on GET, adds or updates an entry on POST and removes one on DELETE (?name=)
+func handleWatchlist(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var entry watchlist.Entry
+		if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+			http.Error(w, fmt.Sprintf("Error decoding request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		if err := watchStore.Put(entry); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid watchlist entry: %v", err), http.StatusBadRequest)
+			return
+		}
+		watchRunner.Sync()
+		log.Printf("Watching %s (%s, priority %d).", entry.Name, entry.Action, entry.Priority)
+	case http.MethodDelete:
+		name := r.URL.Query().Get("name")
+		if err := watchStore.Remove(name); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		watchRunner.Sync()
+		log.Printf("Stopped watching %s.", name)
+	default:
+		http.Error(w, "Only GET, POST and DELETE methods are allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(watchStore.List())
+	if err != nil {
+		log.Printf("Error encoding watchlist: %v", err)
+	}
+}
+
+// Redeems gift codes in the background, progress shows up under giftCodes in /api/stats and in /api/events.
+// the accounts that end up ready are added to the accounts file, or gc.txt if there isn't one.
+func handleGiftCodes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req GiftCodesRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error decoding request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	codes, parseErrors := parser.ParseGiftCodes(strings.Split(strings.ReplaceAll(req.Lines, "\r", ""), "\n"))
+	logErrors(parseErrors)
+	if len(codes) == 0 {
+		http.Error(w, "No email:password:giftcode lines found", http.StatusBadRequest)
+		return
+	}
+
+	go func() {
+		log.Printf("Redeeming %d gift code(s)...", len(codes))
+		ready, errs := claimer.RedeemGiftCodeLines(codes, claimer.AuthOptions{Concurrency: req.AuthConcurrency})
+		logErrors(errs)
+
+		added, err := parser.AddAccounts(AccountsPath, "gc.txt", ready)
+		if err != nil {
+			log.Printf("Error saving redeemed accounts: %v", err)
+			return
+		}
+		log.Printf("%d/%d gift code account(s) ready, %d added.", len(ready), len(codes), added)
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": fmt.Sprintf("Redeeming %d gift code(s). Check /api/stats for progress.", len(codes)),
+	})
+}
+
+// Sends a test event to every configured webhook and reports how each went
+func handleWebhookTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	event := claimer.Event{Type: "test", Time: time.Now(), Message: "Webhook test from MCsniperGO"}
+	results := []WebhookTestResponse{}
+	for _, hook := range notifier.Config().Webhooks {
+		result := WebhookTestResponse{URL: hook.URL, OK: true}
+		if err := notifier.Send(hook, event); err != nil {
+			result.OK = false
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(results)
+	if err != nil {
+		log.Printf("Error encoding webhook test response: %v", err)
+	}
+}
+
+func handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Only GET method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	stats := claimer.StatsSnapshot()
+	resp := StatsResponse{
+		Total:           stats.Total,
+		TooManyRequests: stats.TooManyRequests,
+		Duplicate:       stats.Duplicate,
+		NotAllowed:      stats.NotAllowed,
+		Success:         stats.Success,
+		Cooldowns:       stats.Cooldowns,
+		ProxyPenalties:  stats.ProxyPenalties,
+		Unauthorized:    stats.Unauthorized,
+		Quarantined:     stats.Quarantined,
+		Removed:         stats.Removed,
+		LateSends:       stats.LateSends,
+		Unconfirmed:     stats.Unconfirmed,
+		ClockOffsetMs:   stats.ClockOffset.Milliseconds(),
+		LatencyMs:       stats.Latency.Milliseconds(),
+		Warnings:        stats.Warnings,
+		Types:           map[string]TypeStatsResponse{},
+	}
+	for accType, typeStats := range stats.Types {
+		resp.Types[string(accType)] = TypeStatsResponse{
+			Total:           typeStats.Total,
+			Success:         typeStats.Success,
+			TooManyRequests: typeStats.TooManyRequests,
+		}
+	}
+	resp.Drift = bucketsResponse(stats.Drift)
+	resp.RoundTrip = bucketsResponse(stats.RoundTrip)
+	resp.Auth = authResponse(stats.Auth)
+	resp.GiftCodes = authResponse(stats.GiftCodes)
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		log.Printf("Error encoding stats response: %v", err)
+	}
+}
+
+func handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Only GET method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	eventsMu.Lock()
+	resp := append([]EventResponse{}, events...)
+	eventsMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		log.Printf("Error encoding events response: %v", err)
+	}
+}
 
 // StartWebServer starts the integrated web server
 func StartWebServer(port string) {
@@ -272,6 +825,29 @@ func StartWebServer(port string) {
 	mux.HandleFunc("/api/snipe", handleSnipe)
 	mux.HandleFunc("/api/config/save", handleConfigSave)
 	mux.HandleFunc("/api/config/load", handleConfigLoad)
+	mux.HandleFunc("/api/proxies/check", handleProxyCheck)
+	mux.HandleFunc("/api/stats", handleStats)
+	mux.HandleFunc("/api/events", handleEvents)
+	mux.HandleFunc("/api/webhooks", handleWebhooks)
+	mux.HandleFunc("/api/webhooks/test", handleWebhookTest)
+	mux.HandleFunc("/api/watchlist", handleWatchlist)
+	mux.HandleFunc("/api/giftcodes", handleGiftCodes)
+
+	claimer.Subscribe(recordEvent)
+
+	webhooks, err := notify.LoadConfig(WebhooksPath)
+	if err != nil {
+		log.Printf("Warning: Could not load webhook config '%s': %v", WebhooksPath, err)
+	}
+	notifier = notify.NewNotifier(webhooks)
+	notifier.Attach()
+
+	watchStore, err = watchlist.Load(WatchlistPath)
+	if err != nil {
+		log.Printf("Warning: Could not load watchlist '%s': %v", WatchlistPath, err)
+	}
+	watchRunner = watchlist.NewRunner(watchStore, availability.DefaultInterval, snipeWatched)
+	watchRunner.Start()
 
 	log.Printf("Starting integrated web server on http://localhost%s", port)
 	err = http.ListenAndServe(port, mux)
@@ -42,7 +42,7 @@ func main() {
 	fmt.Printf("Drop time scheduled for: %v\n", dropTime.Format(time.RFC3339))
 
 	// Call the sniper
-	err = claimer.ClaimWithinRange(*username, dropRange, accounts, nil)
+	err = claimer.ClaimWithinRange(*username, dropRange, accounts, nil, claimer.Options{})
 	if err != nil {
 		fmt.Printf("Snipe error: %v\n", err)
 		os.Exit(1)